@@ -29,12 +29,12 @@ type (
 
 	// ControllerRoutable ensures consistency across all controller based types.
 	ControllerRoutable[T RequestContext, RequestData FromRequest[T]] interface {
-		Match(string, string, ControllerHandler[T, RequestData])
-		Get(string, ControllerHandler[T, RequestData])
-		Post(string, ControllerHandler[T, RequestData])
-		Put(string, ControllerHandler[T, RequestData])
-		Patch(string, ControllerHandler[T, RequestData])
-		Delete(string, ControllerHandler[T, RequestData])
+		Match(string, string, ControllerHandler[T, RequestData]) *Route[T]
+		Get(string, ControllerHandler[T, RequestData]) *Route[T]
+		Post(string, ControllerHandler[T, RequestData]) *Route[T]
+		Put(string, ControllerHandler[T, RequestData]) *Route[T]
+		Patch(string, ControllerHandler[T, RequestData]) *Route[T]
+		Delete(string, ControllerHandler[T, RequestData]) *Route[T]
 		Use(...func(context.Context, T, Handler[T]))
 	}
 
@@ -56,7 +56,8 @@ func NewController[Parent RequestContext, RequestData FromRequest[Parent]](r Reg
 		root: &controllerGroup[Parent, RequestData]{
 			prefix:      "",
 			parent:      r,
-			middlewares: make([]func(context.Context, Parent, Handler[Parent]), 0),
+			middlewares: &middlewareStack[Parent]{},
+			metadata:    make(map[string]any),
 		},
 	}
 }
@@ -64,38 +65,43 @@ func NewController[Parent RequestContext, RequestData FromRequest[Parent]](r Reg
 // RawMatch implements the Registerable interface and forwards the call to the
 // parent router. This allows controllers and groups to be registered with the
 // current controller.
-func (r *Controller[T, RequestData]) RawMatch(method string, path string, fn Handler[T]) {
-	r.parent.RawMatch(method, path, fn)
+func (r *Controller[T, RequestData]) RawMatch(method string, path string, fn Handler[T]) *Route[T] {
+	return r.parent.RawMatch(method, path, fn)
+}
+
+// ensureMutable implements the mutable interface.
+func (r *Controller[T, RequestData]) ensureMutable(action string) {
+	ensureMutable(r.parent, action)
 }
 
 // Match registers the given handler with the given method and path.
-func (r *Controller[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Match(method, path, fn)
+func (r *Controller[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.root.Match(method, path, fn)
 }
 
 // Get registers a GET handler with the given path.
-func (r *Controller[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Get(path, fn)
+func (r *Controller[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.root.Get(path, fn)
 }
 
 // Post registers a POST handler with the given path.
-func (r *Controller[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Post(path, fn)
+func (r *Controller[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.root.Post(path, fn)
 }
 
 // Put registers a PUT handler with the given path.
-func (r *Controller[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Put(path, fn)
+func (r *Controller[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.root.Put(path, fn)
 }
 
 // Patch registers a PATCH handler with the given path.
-func (r *Controller[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Patch(path, fn)
+func (r *Controller[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.root.Patch(path, fn)
 }
 
 // Delete registers a DELETE handler with the given path.
-func (r *Controller[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Delete(path, fn)
+func (r *Controller[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.root.Delete(path, fn)
 }
 
 // Group returns a new ControllerGroup.
@@ -108,6 +114,13 @@ func (r *Controller[T, RequestData]) Namespace(prefix string) *controllerGroup[T
 	return r.root.Namespace(prefix)
 }
 
+// Meta sets a metadata value that is inherited by every route registered with
+// the controller.
+func (r *Controller[T, RequestData]) Meta(key string, value any) *Controller[T, RequestData] {
+	r.root.Meta(key, value)
+	return r
+}
+
 // Use registers a middleware function that will be called before each request.
 // Middlewares are always called in the order they are registered and before
 // FromRequest is called.
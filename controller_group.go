@@ -11,7 +11,8 @@ import (
 type controllerGroup[T RequestContext, RequestData FromRequest[T]] struct {
 	prefix      string
 	parent      Registerable[T]
-	middlewares []func(context.Context, T, Handler[T])
+	middlewares *middlewareStack[T]
+	metadata    map[string]any
 }
 
 var _ ControllerRoutable[*RootRequestContext, *placeholderFromRequest] = &Controller[*RootRequestContext, *placeholderFromRequest]{}
@@ -19,72 +20,81 @@ var _ ControllerRoutable[*RootRequestContext, *placeholderFromRequest] = &Contro
 // RawMatch implements the Registerable interface and forwards the call to the
 // parent router. This allows other controllers and controller groups to be
 // registered with the controller.
-func (r *controllerGroup[T, RequestData]) RawMatch(method string, path string, fn Handler[T]) {
-	r.parent.RawMatch(method, joinURL(r.prefix, path), r.wrap(fn))
+func (r *controllerGroup[T, RequestData]) RawMatch(method string, path string, fn Handler[T]) *Route[T] {
+	route := r.parent.RawMatch(method, joinURL(r.prefix, path), fn)
+	route.addMiddlewareStack(r.middlewares)
+	route.addGroupMetadata(r.metadata)
+
+	return route
 }
 
 // Match registers the given handler with the given method and path.
-func (r *controllerGroup[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData]) {
-	r.parent.RawMatch(method, joinURL(r.prefix, path), r.wrap(r.normalizeHandler(fn)))
+func (r *controllerGroup[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.RawMatch(method, path, r.normalizeHandler(fn))
 }
 
 // Get registers a GET handler with the given path.
-func (r *controllerGroup[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodGet, path, fn)
+func (r *controllerGroup[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.Match(http.MethodGet, path, fn)
 }
 
 // Post registers a POST handler with the given path.
-func (r *controllerGroup[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodPost, path, fn)
+func (r *controllerGroup[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.Match(http.MethodPost, path, fn)
 }
 
 // Put registers a PUT handler with the given path.
-func (r *controllerGroup[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodPut, path, fn)
+func (r *controllerGroup[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.Match(http.MethodPut, path, fn)
 }
 
 // Patch registers a PATCH handler with the given path.
-func (r *controllerGroup[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodPatch, path, fn)
+func (r *controllerGroup[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.Match(http.MethodPatch, path, fn)
 }
 
 // Delete registers a DELETE handler with the given path.
-func (r *controllerGroup[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodDelete, path, fn)
+func (r *controllerGroup[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData]) *Route[T] {
+	return r.Match(http.MethodDelete, path, fn)
 }
 
 // Group returns a new controller group with the given prefix.
 func (r *controllerGroup[T, RequestData]) Group() *controllerGroup[T, RequestData] {
 	return &controllerGroup[T, RequestData]{
-		parent: r,
+		parent:      r,
+		middlewares: &middlewareStack[T]{},
+		metadata:    make(map[string]any),
 	}
 }
 
 // Namespace returns a new controller group with the given prefix.
 func (r *controllerGroup[T, RequestData]) Namespace(prefix string) *controllerGroup[T, RequestData] {
 	return &controllerGroup[T, RequestData]{
-		prefix: prefix,
-		parent: r,
+		prefix:      prefix,
+		parent:      r,
+		middlewares: &middlewareStack[T]{},
+		metadata:    make(map[string]any),
 	}
 }
 
+// Meta sets a metadata value that is inherited by every route registered with
+// the controller group and its subgroups.
+func (r *controllerGroup[T, RequestData]) Meta(key string, value any) *controllerGroup[T, RequestData] {
+	r.ensureMutable("set controller metadata")
+	r.metadata[key] = value
+	return r
+}
+
 // Use registers a middleware function that will be called before each handler.
 // Middleware are always called before FromRequest.
 func (r *controllerGroup[T, RequestData]) Use(fns ...func(context.Context, T, Handler[T])) {
-	r.middlewares = append(r.middlewares, fns...)
+	r.ensureMutable("register controller middleware")
+	r.middlewares.use(fns...)
 }
 
-func (r *controllerGroup[T, RequestData]) wrap(fn Handler[T]) Handler[T] {
-	handler := fn
-
-	for _, middleware := range r.middlewares {
-		currentHandler := handler
-		handler = func(ctx context.Context, rc T) {
-			middleware(ctx, rc, currentHandler)
-		}
-	}
-
-	return handler
+// ensureMutable implements the mutable interface.
+func (r *controllerGroup[T, RequestData]) ensureMutable(action string) {
+	ensureMutable(r.parent, action)
 }
 
 func (r *controllerGroup[T, RequestData]) normalizeHandler(fn ControllerHandler[T, RequestData]) Handler[T] {
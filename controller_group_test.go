@@ -47,7 +47,7 @@ func TestController(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		routerFn func(string, ControllerHandler[*RootRequestContext, *PostData])
+		routerFn func(string, ControllerHandler[*RootRequestContext, *PostData]) *Route[*RootRequestContext]
 		method   string
 	}{
 		"GET":    {method: http.MethodGet, routerFn: controller.Get},
@@ -57,10 +57,12 @@ func TestController(t *testing.T) {
 		"DELETE": {method: http.MethodDelete, routerFn: controller.Delete},
 	}
 
+	for _, tc := range tests {
+		tc.routerFn("/foo", handler)
+	}
+
 	for testName, tc := range tests {
 		t.Run(testName, func(t *testing.T) {
-			tc.routerFn("/foo", handler)
-
 			res := httptest.NewRecorder()
 			req := httptest.NewRequest(tc.method, "/foo", nil)
 
@@ -203,6 +205,10 @@ func Test_ControllerMiddleware(t *testing.T) {
 		r.AddToChain("controller use")
 		next(ctx, r)
 	})
+	controller.Use(func(ctx context.Context, r *TrackingRequestContext, next Handler[*TrackingRequestContext]) {
+		r.AddToChain("controller use 2")
+		next(ctx, r)
+	})
 	subGroup := controller.Namespace("/sub")
 	subGroup.Use(func(ctx context.Context, r *TrackingRequestContext, next Handler[*TrackingRequestContext]) {
 		r.AddToChain("subgroup use")
@@ -210,6 +216,9 @@ func Test_ControllerMiddleware(t *testing.T) {
 	})
 	subGroup.Get("/best", func(ctx context.Context, r *TrackingRequestContext, p *TrackingData) {
 		r.AddToChain("handler")
+	}).Use(func(ctx context.Context, r *TrackingRequestContext, next Handler[*TrackingRequestContext]) {
+		r.AddToChain("route use")
+		next(ctx, r)
 	})
 
 	req := httptest.NewRequest("GET", "/comments/sub/best", nil)
@@ -220,7 +229,7 @@ func Test_ControllerMiddleware(t *testing.T) {
 
 	require.Equal(
 		t,
-		[]string{"new", "router use", "group use", "controller use", "subgroup use", "FromRequest", "handler"},
+		[]string{"new", "router use", "group use", "controller use", "controller use 2", "subgroup use", "route use", "FromRequest", "handler"},
 		tracking.Chain,
 		"expected the middleware, FromRequest, and handlers to be called in order",
 	)
@@ -240,3 +249,27 @@ func TestControllerGroup_PrefixRoot(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, res.Code)
 }
+
+func TestController_Metadata(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	var role string
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		role, _ = Meta[string](r, "role")
+		next(ctx, r)
+	})
+
+	controller := NewController(router, &PostData{}).Meta("role", "user")
+	controller.Namespace("/admin").Meta("role", "admin").Get("/", func(ctx context.Context, r *RootRequestContext, p *PostData) {})
+	controller.Get("/posts", func(ctx context.Context, r *RootRequestContext, p *PostData) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/admin", nil)
+	router.ServeHTTP(res, req)
+	require.Equal(t, "admin", role)
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/posts", nil)
+	router.ServeHTTP(res, req)
+	require.Equal(t, "user", role)
+}
@@ -0,0 +1,157 @@
+package fernet
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Fallback handlers are registered as routes using these methods so that they
+// pass through the middleware stacks of the groups they're registered with.
+// Slashes are not valid in HTTP methods so they can't conflict with routes.
+const (
+	notFoundMethod         = "fernet/notfound"
+	methodNotAllowedMethod = "fernet/methodnotallowed"
+)
+
+// isFallbackMethod returns true if method is used to register a fallback
+// handler instead of a route.
+func isFallbackMethod(method string) bool {
+	return method == notFoundMethod || method == methodNotAllowedMethod
+}
+
+// registerFallback registers fn as the fallback for requests to prefix and
+// all paths below it.
+func registerFallback[T RequestContext](r Registerable[T], method string, prefix string, fn Handler[T]) {
+	r.RawMatch(method, prefix, fn)
+	r.RawMatch(method, joinURL(prefix, "/*"), fn)
+}
+
+// routeStatuses are the statuses of the empty responses the router writes
+// for requests whose path matches a route, like 405s. routerStatuses also
+// includes the statuses of the responses written for other requests.
+var (
+	routeStatuses = []int{
+		http.StatusNoContent,
+		http.StatusMethodNotAllowed,
+		http.StatusNotAcceptable,
+		http.StatusUnsupportedMediaType,
+	}
+	routerStatuses = append([]int{
+		http.StatusMovedPermanently,
+		http.StatusPermanentRedirect,
+		http.StatusNotFound,
+	}, routeStatuses...)
+)
+
+// compileEmptyResponses returns a handler for each of the given statuses that
+// writes an empty response with that status, wrapped by the given middleware.
+// They're compiled once so the middleware chain isn't rebuilt for each
+// request.
+func compileEmptyResponses[T RequestContext](middleware []*middlewareEntry[T], statuses []int) map[int]Handler[T] {
+	responders := make(map[int]Handler[T], len(statuses))
+	for _, status := range statuses {
+		status := status
+		responders[status] = chain(middleware, func(ctx context.Context, rctx T) {
+			rctx.Response().WriteHeader(status)
+		})
+	}
+
+	return responders
+}
+
+// respond returns a handler that runs the router's middleware and writes an
+// empty response with the given status. If header isn't empty, it's set to
+// value on the response of rctx before the middleware runs.
+func (r *Router[T]) respond(rctx *RootRequestContext, status int, header string, value string) Handler[T] {
+	return respondWith(r.responders, rctx, status, header, value)
+}
+
+// respondFor is like respond, but runs the middleware of the router and
+// groups route was registered through and sets the metadata of rctx to the
+// metadata of those groups. It's used for responses to requests whose path
+// matches route, so they pass through the same group middleware the route
+// would, without the middleware and metadata registered for only the route.
+func (r *Router[T]) respondFor(route *Route[T], rctx *RootRequestContext, status int, header string, value string) Handler[T] {
+	rctx.metadata = route.compiledGroupMetadata
+
+	return respondWith(route.compiledResponders, rctx, status, header, value)
+}
+
+// respondWith returns the handler in responders for status, setting header to
+// value on the response of rctx if header isn't empty.
+func respondWith[T RequestContext](responders map[int]Handler[T], rctx *RootRequestContext, status int, header string, value string) Handler[T] {
+	if header != "" {
+		rctx.writer.Header().Set(header, value)
+	}
+
+	return responders[status]
+}
+
+// fallbackHandler returns the handler of fallback, setting the metadata of
+// rctx to the metadata of the groups it was registered with.
+func fallbackHandler[T RequestContext](fallback *Route[T], rctx *RootRequestContext) Handler[T] {
+	rctx.metadata = fallback.compiledMetadata
+
+	return fallback.compiled
+}
+
+// notFoundHandler returns the handler for requests that did not match a
+// route. The most specific NotFound handler registered for the path is used,
+// falling back to an empty 404 response.
+func (r *Router[T]) notFoundHandler(trees []matchedTree[T], rctx *RootRequestContext, pathParts []string) Handler[T] {
+	if fallback, ok := lookupFallback(trees, notFoundMethod, pathParts); ok {
+		return fallbackHandler(fallback, rctx)
+	}
+
+	return r.respond(rctx, http.StatusNotFound, "", "")
+}
+
+// methodNotAllowedHandler returns the handler for requests whose path matches
+// routes registered for other methods. The Allow header is set before the
+// most specific MethodNotAllowed handler registered for the path is called,
+// falling back to an empty 405 response that runs the group middleware of
+// route.
+func (r *Router[T]) methodNotAllowedHandler(trees []matchedTree[T], rctx *RootRequestContext, pathParts []string, allowed []string, route *Route[T]) Handler[T] {
+	allow := strings.Join(allowed, ", ")
+
+	fallback, ok := lookupFallback(trees, methodNotAllowedMethod, pathParts)
+	if !ok {
+		return r.respondFor(route, rctx, http.StatusMethodNotAllowed, "Allow", allow)
+	}
+
+	handler := fallbackHandler(fallback, rctx)
+
+	return func(ctx context.Context, rctx T) {
+		rctx.Response().Header().Set("Allow", allow)
+		handler(ctx, rctx)
+	}
+}
+
+// unmatchedHandler returns the handler for requests whose method and path
+// match routes but whose matchers reject the request. 404s use the NotFound
+// handler registered for the path, while 415s and 406s respond with an empty
+// body after running the group middleware of route.
+func (r *Router[T]) unmatchedHandler(trees []matchedTree[T], rctx *RootRequestContext, pathParts []string, status int, route *Route[T]) Handler[T] {
+	if status == http.StatusNotFound {
+		return r.notFoundHandler(trees, rctx, pathParts)
+	}
+
+	return r.respondFor(route, rctx, status, "", "")
+}
+
+// lookupFallback returns the fallback registered with the given method in
+// the first tree that has one for the path.
+func lookupFallback[T RequestContext](trees []matchedTree[T], method string, pathParts []string) (*Route[T], bool) {
+	lookup := make([]string, 0, len(pathParts)+1)
+	lookup = append(lookup, method)
+	lookup = append(lookup, pathParts...)
+
+	for _, tree := range trees {
+		if ok, value := tree.tree.Value(lookup); ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
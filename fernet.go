@@ -2,10 +2,13 @@ package fernet
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
-
-	"github.com/blakewilliams/fernet/internal/radical"
+	"sync"
+	"sync/atomic"
 )
 
 type (
@@ -19,12 +22,27 @@ type (
 
 	// Router represents the primary router for the application.
 	Router[T RequestContext] struct {
-		routes           []*route[T]
-		tree             *radical.Node[*route[T]]
-		middleware       []func(context.Context, T, Handler[T])
-		metal            []func(w http.ResponseWriter, r *http.Request, next http.Handler)
-		initT            func(RequestContext) T
-		anyRoutesDefined bool
+		routes     []*Route[T]
+		hosts      []*hostPattern
+		middleware *middlewareStack[T]
+		metal      []func(w http.ResponseWriter, r *http.Request, next http.Handler)
+		initT      func(RequestContext) T
+		options    options
+		// frozen is set once the router starts serving requests, after which
+		// handler is the precompiled handler for every request.
+		frozen     atomic.Bool
+		freezeOnce sync.Once
+		handler    http.Handler
+		// responders are the empty responses the router writes itself, like
+		// 404s, by status. They're wrapped by the router's middleware when
+		// the router is frozen.
+		responders map[int]Handler[T]
+		// table is the compiled route table requests are served from. It's
+		// replaced by Update while the router is serving requests, and
+		// updating is set while the function passed to Update runs.
+		table    atomic.Pointer[routeTable[T]]
+		updateMu sync.Mutex
+		updating atomic.Bool
 	}
 
 	// Registerable is an interface that can be implemented by types that want
@@ -32,24 +50,24 @@ type (
 	// by internal or external packages like Group, and Controller.
 	Registerable[T RequestContext] interface {
 		// RawMatch registers a route with the given method and path
-		RawMatch(method string, path string, fn Handler[T])
+		RawMatch(method string, path string, fn Handler[T]) *Route[T]
 	}
 
 	// Routable is an interface that can be implemented by types that want to
 	// register routes with a router.
 	Routable[T RequestContext] interface {
 		// Match registers a route with the given method and path
-		Match(method string, path string, fn Handler[T])
+		Match(method string, path string, fn Handler[T]) *Route[T]
 		// Get registers a GET route with the given path
-		Get(method string, fn Handler[T])
+		Get(method string, fn Handler[T]) *Route[T]
 		// Post registers a POST route with the given path
-		Post(method string, fn Handler[T])
+		Post(method string, fn Handler[T]) *Route[T]
 		// Put registers a PUT route with the given path
-		Put(method string, fn Handler[T])
+		Put(method string, fn Handler[T]) *Route[T]
 		// Patch registers a PATCH route with the given path
-		Patch(method string, fn Handler[T])
+		Patch(method string, fn Handler[T]) *Route[T]
 		// Delete registers a DELETE route with the given path
-		Delete(method string, fn Handler[T])
+		Delete(method string, fn Handler[T]) *Route[T]
 
 		// Use registers a middleware function that is run before each request
 		// for this group and all groups below it.
@@ -63,6 +81,17 @@ type (
 		// Namespace is like Group but accepts a prefix that will be included in
 		// the path of all routes registered with the group.
 		Namespace(prefix string) *Group[T]
+
+		// Mount registers an http.Handler for all requests to the prefix and
+		// the paths below it.
+		Mount(prefix string, handler http.Handler)
+
+		// NotFound registers a handler for requests that don't match a route.
+		NotFound(fn Handler[T])
+
+		// MethodNotAllowed registers a handler for requests whose path only
+		// matches routes registered for other methods.
+		MethodNotAllowed(fn Handler[T])
 	}
 )
 
@@ -72,11 +101,17 @@ var _ Registerable[*RootRequestContext] = (*Router[*RootRequestContext])(nil)
 // New returns a new router with the given RequestContext type. The function
 // passed to this function is used to initialize the RequestContext for each
 // request which is then passed to the relevant route handler.
-func New[T RequestContext](init func(RequestContext) T) *Router[T] {
+//
+// Options can be passed to customize the behavior of the router.
+func New[T RequestContext](init func(RequestContext) T, opts ...Option) *Router[T] {
 	r := &Router[T]{
-		tree:       radical.New[*route[T]](),
-		middleware: make([]func(context.Context, T, Handler[T]), 0),
+		middleware: &middlewareStack[T]{},
 		initT:      init,
+		options:    defaultOptions(),
+	}
+
+	for _, opt := range opts {
+		opt(&r.options)
 	}
 
 	return r
@@ -84,71 +119,131 @@ func New[T RequestContext](init func(RequestContext) T) *Router[T] {
 
 // RawMatch implements the Registerable interface and registers a route with the
 // router.
-func (r *Router[T]) RawMatch(method string, path string, handler Handler[T]) {
-	r.Match(method, path, handler)
+func (r *Router[T]) RawMatch(method string, path string, handler Handler[T]) *Route[T] {
+	return r.Match(method, path, handler)
 }
 
 // Match registers a route with the router.
-func (r *Router[T]) Match(method string, path string, handler Handler[T]) {
-	r.anyRoutesDefined = true
+func (r *Router[T]) Match(method string, path string, handler Handler[T]) *Route[T] {
+	return r.addRoute(nil, method, path, handler)
+}
 
-	route := newRoute[T](method, path, r.wrap(handler))
-	r.routes = append(r.routes, route)
+// addRoute registers a route with the router, restricting it to requests
+// matching host if it's not nil. Routes are added to the route tree when the
+// router is frozen or updated.
+func (r *Router[T]) addRoute(host *hostPattern, method string, path string, handler Handler[T]) *Route[T] {
+	r.ensureUpdatable(fmt.Sprintf("register route %s %s", method, path))
+
+	route := newRoute[T](method, path, handler)
+	route.ensureMutable = r.ensureUpdatable
+	route.source, route.line = callerLocation()
+	route.addMiddlewareStack(r.middleware)
 
-	pathParts := make([]string, 0, len(route.parts)+1)
-	pathParts = append(pathParts, method)
-	pathParts = append(pathParts, route.parts...)
+	if host != nil {
+		route.hostPattern = host
+		route.host = host.raw
+	}
 
-	r.tree.Add(pathParts, route)
+	r.routes = append(r.routes, route)
+
+	return route
 }
 
 // Get registers a GET route with the router.
-func (r *Router[T]) Get(path string, handler Handler[T]) {
-	r.Match(http.MethodGet, path, handler)
+func (r *Router[T]) Get(path string, handler Handler[T]) *Route[T] {
+	return r.Match(http.MethodGet, path, handler)
 }
 
 // Get registers a GET route with the router.
-func (r *Router[T]) Post(path string, handler Handler[T]) {
-	r.Match(http.MethodPost, path, handler)
+func (r *Router[T]) Post(path string, handler Handler[T]) *Route[T] {
+	return r.Match(http.MethodPost, path, handler)
 }
 
 // Put registers a PUT route with the router.
-func (r *Router[T]) Put(path string, handler Handler[T]) {
-	r.Match(http.MethodPut, path, handler)
+func (r *Router[T]) Put(path string, handler Handler[T]) *Route[T] {
+	return r.Match(http.MethodPut, path, handler)
 }
 
 // Patch registers a PATCH route with the router.
-func (r *Router[T]) Patch(path string, handler Handler[T]) {
-	r.Match(http.MethodPatch, path, handler)
+func (r *Router[T]) Patch(path string, handler Handler[T]) *Route[T] {
+	return r.Match(http.MethodPatch, path, handler)
 }
 
 // Delete registers a DELETE route with the router.
-func (r *Router[T]) Delete(path string, handler Handler[T]) {
-	r.Match(http.MethodDelete, path, handler)
+func (r *Router[T]) Delete(path string, handler Handler[T]) *Route[T] {
+	return r.Match(http.MethodDelete, path, handler)
 }
 
 // Use registers middleware that will be run before each handler, including
-// the handlers of groups and controllers.
+// the handlers of groups and controllers. Middleware can be registered at any
+// time before the router is frozen and applies to routes registered before
+// and after it. Router middleware runs in the order it was registered, before
+// the middleware of groups and routes.
 func (r *Router[T]) Use(fns ...func(context.Context, T, Handler[T])) {
-	if r.anyRoutesDefined {
-		panic("Use can only be called before routes are defined")
-	}
+	r.ensureMutable("register middleware")
+	r.middleware.use(fns...)
+}
 
-	r.middleware = append(r.middleware, fns...)
+// UseNamed registers middleware like Use, with a name that other middleware
+// can be inserted relative to using UseBefore and UseAfter. The name is also
+// used to describe the middleware in Routes.
+func (r *Router[T]) UseNamed(name string, fn func(context.Context, T, Handler[T])) {
+	r.ensureMutable("register middleware")
+	r.middleware.useNamed(name, fn)
+}
+
+// UseBefore registers middleware that runs immediately before the router
+// middleware registered with UseNamed using the given name. The named
+// middleware can be registered before or after calling UseBefore, but the
+// router panics when it's frozen if the name isn't registered.
+func (r *Router[T]) UseBefore(name string, fns ...func(context.Context, T, Handler[T])) {
+	r.ensureMutable("register middleware")
+	r.middleware.useRelative(name, false, fns...)
+}
+
+// UseAfter is like UseBefore but registers middleware that runs immediately
+// after the named middleware.
+func (r *Router[T]) UseAfter(name string, fns ...func(context.Context, T, Handler[T])) {
+	r.ensureMutable("register middleware")
+	r.middleware.useRelative(name, true, fns...)
 }
 
 // UseMetal registers "metal" middleware (net/http based) that will be run
 // before the fernet middleware stack and route handler. This is useful for
 // when the underlying http.ResponseWriter or *http.Request need to be
-// modified before fernet uses them.
+// modified before fernet uses them. Like Use, it can be called at any time
+// before the router is frozen.
 func (r *Router[T]) UseMetal(fns ...func(w http.ResponseWriter, r *http.Request, next http.Handler)) {
-	if r.anyRoutesDefined {
-		panic("UseMetal can only be called before routes are defined")
-	}
-
+	r.ensureMutable("register metal middleware")
 	r.metal = append(r.metal, fns...)
 }
 
+// Mount registers an http.Handler that will handle requests of any method
+// for the given prefix and all paths below it. The prefix is stripped from the
+// request path before the handler is called and the handler writes to the
+// fernet Response, so fernet middleware runs for mounted handlers too.
+//
+// Mount can be used to serve another Router, even one with a different
+// RequestContext type.
+func (r *Router[T]) Mount(prefix string, handler http.Handler) {
+	mount[T](r, prefix, handler)
+}
+
+// NotFound registers a handler that is called when no route matches the
+// request. NotFound handlers registered on a Namespace take priority for
+// requests under the namespace's prefix.
+func (r *Router[T]) NotFound(fn Handler[T]) {
+	registerFallback[T](r, notFoundMethod, "/", fn)
+}
+
+// MethodNotAllowed registers a handler that is called when the request path
+// matches routes registered for other methods. The Allow header is set before
+// the handler is called. MethodNotAllowed handlers registered on a Namespace
+// take priority for requests under the namespace's prefix.
+func (r *Router[T]) MethodNotAllowed(fn Handler[T]) {
+	registerFallback[T](r, methodNotAllowedMethod, "/", fn)
+}
+
 // Group returns a new route group that can define its own middleware
 // that will only be run for that group.
 func (r *Router[T]) Group() *Group[T] {
@@ -161,71 +256,268 @@ func (r *Router[T]) Namespace(prefix string) *Group[T] {
 	return NewGroup[T](r, prefix)
 }
 
-// ServeHTTP implements the http.Handler interface.
+// Routes returns information about each route registered with the router in
+// the order they were registered. Mounts are listed once, using the wildcard
+// path that covers the prefix and all paths below it.
+func (r *Router[T]) Routes() []RouteInfo {
+	registered := r.currentRoutes()
+
+	routes := make([]RouteInfo, 0, len(registered))
+	for _, route := range registered {
+		if isFallbackMethod(route.Method) {
+			continue
+		}
+
+		// Mount registers the prefix and the wildcard below it as separate
+		// routes, only the wildcard is listed.
+		if route.Method == anyMethod && !route.isWildcard() {
+			continue
+		}
+
+		routes = append(routes, route.info())
+	}
+
+	return routes
+}
+
+// URL returns the path for the route registered with the given name. Params
+// are provided as key value pairs and are used to fill in the named and
+// wildcard segments of the route, e.g.
+//
+//	router.Get("/users/:id", handler).Name("user")
+//	router.URL("user", "id", "5") // "/users/5"
+//
+// An error is returned if no route has the given name, a param is missing or
+// does not satisfy its constraint, or if unknown params are provided.
+func (r *Router[T]) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("params for route %q must be provided as key value pairs", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	for _, route := range r.currentRoutes() {
+		if route.name == name && !isFallbackMethod(route.Method) {
+			return route.url(values)
+		}
+	}
+
+	return "", fmt.Errorf("no route named %q", name)
+}
+
+// ServeHTTP implements the http.Handler interface. The router is frozen by
+// the first call to ServeHTTP.
 func (r *Router[T]) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	httpHandler := func(rw http.ResponseWriter, req *http.Request) {
-		// Run fernet middleware and call route handler
-		method := req.Method
-		normalizedPath := normalizeRoutePath(req.URL.Path)
-		lookup := []string{method}
-		lookup = append(lookup, normalizedPath...)
-
-		var handler func(context.Context, T)
-		var params map[string]string
-		var path string
-
-		ok, value := r.tree.Value(lookup)
-		if ok {
-			handler = value.handler
-			path = value.Path
-
-			var ok bool
-			ok, params = value.match(req)
-			if !ok && !value.isWildcard() {
-				// This should never actually get hit in real code but would
-				// indicate a bug in the framework.
-				panic("route did not match request. this is a bug in fernet. please open an issue reporting this error and how to reproduce it.")
+	r.Freeze()
+	r.handler.ServeHTTP(rw, req)
+}
+
+// serveRoute runs the fernet middleware and handler of the route matching the
+// request. Request contexts are taken from a pool and returned to it once the
+// response has been flushed.
+func (r *Router[T]) serveRoute(rw http.ResponseWriter, req *http.Request) {
+	reqCtx := acquireRequestContext(req, rw)
+
+	handler := r.handlerFor(r.table.Load(), reqCtx)
+	handler(
+		req.Context(),
+		r.initT(reqCtx),
+	)
+
+	reqCtx.Response().Flush()
+	releaseRequestContext(reqCtx)
+}
+
+// handlerFor returns the handler in table that should be run for the request
+// of rctx, setting the matched route path, params, and metadata of rctx. If no
+// route matches, a handler that redirects, or responds with a 405 or 404 is
+// returned.
+func (r *Router[T]) handlerFor(table *routeTable[T], rctx *RootRequestContext) Handler[T] {
+	req := rctx.req
+
+	// Requests like `OPTIONS *` and CONNECT don't have a path to clean.
+	if r.options.cleanPathRedirect && req.Method != http.MethodConnect && strings.HasPrefix(req.URL.Path, "/") {
+		if cleaned := cleanPath(req.URL.Path); cleaned != req.URL.Path {
+			return r.redirect(rctx, cleaned)
+		}
+	}
+
+	rctx.parts = appendRoutePath(rctx.parts[:0], req.URL.Path)
+	normalizedPath := rctx.parts
+	trees := table.treesFor(req)
+
+	if value, hostParams, ok := r.lookup(trees, req.Method, normalizedPath); ok {
+		if candidates, ok := table.candidates[value]; ok {
+			var status int
+			if value, status = selectCandidate(candidates, req, normalizedPath); value == nil {
+				return r.unmatchedHandler(trees, rctx, normalizedPath, status, candidates[0])
 			}
-		} else {
-			params = map[string]string{}
-			handler = r.wrap(func(ctx context.Context, rctx T) {
-				rctx.Response().WriteHeader(http.StatusNotFound)
-			})
 		}
 
-		reqCtx := NewRequestContext(req, rw, path, params)
-		handler(
-			req.Context(),
-			r.initT(reqCtx),
-		)
+		params, ok := value.capture(req.URL.Path, normalizedPath, rctx.params)
+		if !ok {
+			// This should never actually get hit in real code but would
+			// indicate a bug in the framework.
+			panic("route did not match request. this is a bug in fernet. please open an issue reporting this error and how to reproduce it.")
+		}
 
-		reqCtx.Response().Flush()
+		for key, value := range hostParams {
+			if _, ok := params.Get(key); !ok {
+				params = append(params, Param{Key: key, Value: value})
+			}
+		}
+
+		rctx.matchedPath = value.Path
+		rctx.params = params
+		rctx.metadata = value.compiledMetadata
+
+		return value.compiled
 	}
 
-	for i := len(r.metal) - 1; i >= 0; i-- {
-		currentHandler := httpHandler
-		m := r.metal[i]
+	if r.options.trailingSlash == TrailingSlashRedirect && req.URL.Path != "/" {
+		toggled := req.URL.Path + "/"
+		if strings.HasSuffix(req.URL.Path, "/") {
+			toggled = strings.TrimSuffix(req.URL.Path, "/")
+		}
+
+		if _, _, ok := r.lookup(trees, req.Method, normalizeRoutePath(toggled)); ok {
+			return r.redirect(rctx, toggled)
+		}
+	}
 
-		httpHandler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-			m(rw, req, http.HandlerFunc(currentHandler))
-		})
+	if r.options.caseInsensitiveRedirect {
+		if value, _, ok := r.lookupFold(trees, req.Method, normalizedPath); ok {
+			if canonical := value.canonicalPath(normalizedPath); canonical != req.URL.Path {
+				return r.redirect(rctx, canonical)
+			}
+		}
 	}
 
-	httpHandler(rw, req)
+	if allowed, route := r.allowedMethods(trees, normalizedPath); len(allowed) > 0 {
+		if req.Method == http.MethodOptions && r.options.implicitOptions {
+			return r.respondFor(route, rctx, http.StatusNoContent, "Allow", strings.Join(allowed, ", "))
+		}
+
+		return r.methodNotAllowedHandler(trees, rctx, normalizedPath, allowed, route)
+	}
+
+	return r.notFoundHandler(trees, rctx, normalizedPath)
+}
+
+// redirect returns a handler that permanently redirects the request to path,
+// preserving the query string. GET and HEAD requests are redirected with a
+// 301 while other methods use a 308 so the method and body are preserved.
+func (r *Router[T]) redirect(rctx *RootRequestContext, path string) Handler[T] {
+	req := rctx.req
+	location := (&url.URL{Path: path, RawQuery: req.URL.RawQuery}).String()
+
+	status := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+
+	return r.respond(rctx, status, "Location", location)
 }
 
-func (r *Router[T]) wrap(fn Handler[T]) func(context.Context, T) {
-	handler := fn
+// lookup returns the route registered for the given method and path segments
+// in the first of the trees containing a match, along with the params of the
+// host that tree belongs to. HEAD requests fall back to the GET route for the
+// path unless implicit HEAD handling has been disabled. Routes registered for
+// any method, like mounted handlers, are checked last.
+func (r *Router[T]) lookup(trees []matchedTree[T], method string, pathParts []string) (*Route[T], map[string]string, bool) {
+	return r.lookupWith(trees, false, method, pathParts)
+}
+
+// lookupFold is like lookup but static segments of the path are compared
+// case-insensitively.
+func (r *Router[T]) lookupFold(trees []matchedTree[T], method string, pathParts []string) (*Route[T], map[string]string, bool) {
+	return r.lookupWith(trees, true, method, pathParts)
+}
+
+func (r *Router[T]) lookupWith(trees []matchedTree[T], fold bool, method string, pathParts []string) (*Route[T], map[string]string, bool) {
+	for _, tree := range trees {
+		lookupMethod := func(method string) (*Route[T], bool) {
+			methodTree := tree.tree.Child(method)
+			if methodTree == nil {
+				return nil, false
+			}
+
+			if fold {
+				ok, value := methodTree.ValueFold(pathParts)
+				return value, ok
+			}
+
+			ok, value := methodTree.Value(pathParts)
+			return value, ok
+		}
+
+		if value, ok := lookupMethod(method); ok {
+			return value, tree.params, true
+		}
+
+		if method == http.MethodHead && r.options.implicitHead {
+			if value, ok := lookupMethod(http.MethodGet); ok {
+				return value, tree.params, true
+			}
+		}
+
+		if value, ok := lookupMethod(anyMethod); ok {
+			return value, tree.params, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// allowedMethods returns the sorted list of methods that have a route
+// registered in any of the trees matching the given path segments, including
+// the implicitly handled HEAD and OPTIONS methods, along with the route
+// registered for the path through the most deeply nested group.
+func (r *Router[T]) allowedMethods(trees []matchedTree[T], pathParts []string) ([]string, *Route[T]) {
+	values := make(map[string]*Route[T])
+	for _, tree := range trees {
+		for method, value := range tree.tree.ValuesAcross(pathParts) {
+			values[method] = value
+		}
+	}
+
+	delete(values, anyMethod)
+	delete(values, notFoundMethod)
+	delete(values, methodNotAllowedMethod)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	methods := make([]string, 0, len(values)+2)
+	for method := range values {
+		methods = append(methods, method)
+	}
+
+	if _, ok := values[http.MethodHead]; !ok && r.options.implicitHead {
+		if _, ok := values[http.MethodGet]; ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+
+	if _, ok := values[http.MethodOptions]; !ok && r.options.implicitOptions {
+		methods = append(methods, http.MethodOptions)
+	}
+
+	sort.Strings(methods)
 
-	for i := len(r.middleware) - 1; i >= 0; i-- {
-		currentHandler := handler
-		middleware := r.middleware[i]
-		handler = func(ctx context.Context, reqCtx T) {
-			middleware(ctx, reqCtx, currentHandler)
+	// The route registered through the most deeply nested group is used for
+	// the responses the router writes for the path, like 405s.
+	var route *Route[T]
+	for _, method := range methods {
+		if value, ok := values[method]; ok && (route == nil || len(value.stacks) > len(route.stacks)) {
+			route = value
 		}
 	}
 
-	return handler
+	return methods, route
 }
 
 func joinURL(prefix string, path string) string {
@@ -0,0 +1,132 @@
+package fernet
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func benchmarkMiddleware(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+	next(ctx, r)
+}
+
+func newBenchmarkRouter() *Router[*RootRequestContext] {
+	router := New(WithBasicRequestContext)
+	router.Use(benchmarkMiddleware, benchmarkMiddleware)
+
+	api := router.Namespace("/api")
+	api.Use(benchmarkMiddleware)
+	api.Get("/users/:id", func(ctx context.Context, r *RootRequestContext) {}).Use(benchmarkMiddleware)
+
+	return router
+}
+
+func BenchmarkRouter_ServeHTTP(b *testing.B) {
+	router := newBenchmarkRouter()
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/users/1", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(res, req)
+	}
+}
+
+// BenchmarkRoute_Compile measures building a route's middleware chain, which
+// happened on every request before routers were frozen.
+func BenchmarkRoute_Compile(b *testing.B) {
+	router := newBenchmarkRouter()
+	route := router.routes[0]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = route.compile()
+	}
+}
+
+// mockResponseWriter is a ResponseWriter that discards everything written to
+// it, so benchmarks only measure the router.
+type mockResponseWriter struct {
+	header http.Header
+}
+
+func (m *mockResponseWriter) Header() http.Header {
+	return m.header
+}
+
+func (m *mockResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (m *mockResponseWriter) WriteHeader(int) {}
+
+func benchmarkRequest(b *testing.B, router http.Handler, req *http.Request) {
+	w := &mockResponseWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkRouter_Static(b *testing.B) {
+	router := New(WithBasicRequestContext)
+	for _, path := range []string{"/", "/cmd.html", "/code.html", "/contrib.html", "/doc/", "/doc/go_faq.html", "/pkg/"} {
+		router.Get(path, func(context.Context, *RootRequestContext) {})
+	}
+
+	benchmarkRequest(b, router, httptest.NewRequest(http.MethodGet, "/doc/go_faq.html", nil))
+}
+
+func BenchmarkRouter_Param(b *testing.B) {
+	router := New(WithBasicRequestContext)
+	router.Get("/user/:name", func(context.Context, *RootRequestContext) {})
+
+	benchmarkRequest(b, router, httptest.NewRequest(http.MethodGet, "/user/gordon", nil))
+}
+
+func BenchmarkRouter_Param5(b *testing.B) {
+	router := New(WithBasicRequestContext)
+	router.Get("/:a/:b/:c/:d/:e", func(context.Context, *RootRequestContext) {})
+
+	benchmarkRequest(b, router, httptest.NewRequest(http.MethodGet, "/test/test/test/test/test", nil))
+}
+
+func BenchmarkRouter_Param20(b *testing.B) {
+	names := make([]string, 20)
+	values := make([]string, 20)
+	for i := range names {
+		names[i] = ":" + string(rune('a'+i))
+		values[i] = string(rune('a' + i))
+	}
+
+	router := New(WithBasicRequestContext)
+	router.Get("/"+strings.Join(names, "/"), func(context.Context, *RootRequestContext) {})
+
+	benchmarkRequest(b, router, httptest.NewRequest(http.MethodGet, "/"+strings.Join(values, "/"), nil))
+}
+
+func BenchmarkRouter_ParamWrite(b *testing.B) {
+	router := New(WithBasicRequestContext)
+	router.Get("/user/:name", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = io.WriteString(r.Response(), r.Param("name"))
+	})
+
+	benchmarkRequest(b, router, httptest.NewRequest(http.MethodGet, "/user/gordon", nil))
+}
+
+func BenchmarkRouter_Wildcard(b *testing.B) {
+	router := New(WithBasicRequestContext)
+	router.Get("/src/*filepath", func(context.Context, *RootRequestContext) {})
+
+	benchmarkRequest(b, router, httptest.NewRequest(http.MethodGet, "/src/some/file/deep/in/the/tree.go", nil))
+}
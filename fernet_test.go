@@ -20,7 +20,7 @@ func TestRouter(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		routerFn func(string, Handler[*RootRequestContext])
+		routerFn func(string, Handler[*RootRequestContext]) *Route[*RootRequestContext]
 		method   string
 	}{
 		"GET":    {method: http.MethodGet, routerFn: router.Get},
@@ -30,10 +30,12 @@ func TestRouter(t *testing.T) {
 		"DELETE": {method: http.MethodDelete, routerFn: router.Delete},
 	}
 
+	for _, tc := range tests {
+		tc.routerFn("/foo", handler)
+	}
+
 	for testName, tc := range tests {
 		t.Run(testName, func(t *testing.T) {
-			tc.routerFn("/foo", handler)
-
 			res := httptest.NewRecorder()
 			req := httptest.NewRequest(tc.method, "/foo", nil)
 
@@ -96,6 +98,132 @@ func TestRouter_Missing(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, res.Code)
 }
 
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("x-middleware", "true")
+		next(ctx, r)
+	})
+
+	router.Get("/hello/:name", func(ctx context.Context, r *RootRequestContext) {})
+	router.Post("/hello/:name", func(ctx context.Context, r *RootRequestContext) {})
+	router.Delete("/goodbye", func(ctx context.Context, r *RootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/hello/fox", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header().Get("Allow"))
+	require.Equal(t, "true", res.Header().Get("x-middleware"))
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/hello", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+	require.Empty(t, res.Header().Get("Allow"))
+	require.Equal(t, "true", res.Header().Get("x-middleware"))
+}
+
+func TestRouter_ImplicitHead(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/hello/:name", func(ctx context.Context, r *RootRequestContext) {
+		r.Response().Header().Set("x-name", r.Params()["name"])
+		_, _ = r.Response().Write([]byte("Hello world"))
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodHead, "/hello/fox", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "fox", res.Header().Get("x-name"))
+	require.Equal(t, "11", res.Header().Get("Content-Length"))
+	require.Empty(t, res.Body.String())
+}
+
+func TestRouter_ImplicitHead_Override(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("Hello world"))
+	})
+	router.Match(http.MethodHead, "/", func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusAccepted)
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodHead, "/", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusAccepted, res.Code)
+	require.Empty(t, res.Header().Get("Content-Length"))
+}
+
+func TestRouter_ImplicitHead_Disabled(t *testing.T) {
+	router := New(WithBasicRequestContext, WithoutImplicitHead())
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodHead, "/", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "GET, OPTIONS", res.Header().Get("Allow"))
+}
+
+func TestRouter_ImplicitOptions(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("x-middleware", "true")
+		next(ctx, r)
+	})
+	router.Get("/hello/:name", func(ctx context.Context, r *RootRequestContext) {})
+	router.Delete("/hello/:name", func(ctx context.Context, r *RootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/hello/fox", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Equal(t, "DELETE, GET, HEAD, OPTIONS", res.Header().Get("Allow"))
+	require.Equal(t, "true", res.Header().Get("x-middleware"))
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodOptions, "/goodbye", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouter_ImplicitOptions_Override(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {})
+	router.Match(http.MethodOptions, "/", func(ctx context.Context, r *RootRequestContext) {
+		r.Response().Header().Set("Allow", "GET")
+		r.Response().WriteHeader(http.StatusOK)
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "GET", res.Header().Get("Allow"))
+}
+
+func TestRouter_ImplicitOptions_Disabled(t *testing.T) {
+	router := New(WithBasicRequestContext, WithoutImplicitOptions())
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "GET, HEAD", res.Header().Get("Allow"))
+}
+
 type contextKey struct{}
 type beforeContextKey struct{}
 
@@ -162,12 +290,234 @@ func TestRouter_Params(t *testing.T) {
 	require.Equal(t, "Hello fox", res.Body.String())
 }
 
+func TestRouter_PooledRequestContexts(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	router.Get("/users/:id", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte(r.Param("id")))
+	})
+	router.Get("/users/:id/posts/:post_id", func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusCreated)
+		_, _ = r.Response().Write([]byte(r.Param("id") + "/" + r.Param("post_id")))
+	})
+	router.Get("/static", func(ctx context.Context, r *RootRequestContext) {
+		require.Empty(t, r.Params())
+		require.Equal(t, "", r.Param("id"))
+	})
+
+	// Serve requests back to back so that request contexts are reused.
+	for i := 0; i < 10; i++ {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", "/users/1/posts/2", nil))
+		require.Equal(t, http.StatusCreated, res.Code)
+		require.Equal(t, "1/2", res.Body.String())
+
+		res = httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", "/users/3", nil))
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "3", res.Body.String())
+
+		res = httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", "/static", nil))
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "", res.Body.String())
+	}
+}
+
+func TestRouter_RouteMiddleware(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	var chain []string
+	track := func(name string) func(context.Context, *RootRequestContext, Handler[*RootRequestContext]) {
+		return func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+			chain = append(chain, name)
+			next(ctx, r)
+		}
+	}
+
+	router.Use(track("router"))
+	group := router.Namespace("/api")
+	group.Use(track("group"))
+
+	group.Get("/tracked", func(ctx context.Context, r *RootRequestContext) {
+		chain = append(chain, "handler")
+	}).Use(track("route 1"), track("route 2")).Use(track("route 3"))
+	group.Get("/untracked", func(ctx context.Context, r *RootRequestContext) {
+		chain = append(chain, "handler")
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/tracked", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, []string{"router", "group", "route 1", "route 2", "route 3", "handler"}, chain)
+
+	chain = nil
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/untracked", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, []string{"router", "group", "handler"}, chain)
+}
+
+func TestRouter_Metadata(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	var role string
+	var authenticated bool
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		role, _ = Meta[string](r, "role")
+		authenticated, _ = Meta[bool](r, "auth")
+		next(ctx, r)
+	})
+
+	api := router.Namespace("/api").Meta("auth", true).Meta("role", "user")
+	admin := api.Namespace("/admin").Meta("role", "admin")
+	admin.Get("/users", func(ctx context.Context, r *RootRequestContext) {})
+	admin.Get("/public", func(ctx context.Context, r *RootRequestContext) {}).Meta("auth", false)
+	api.Get("/me", func(ctx context.Context, r *RootRequestContext) {})
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {
+		_, ok := r.Metadata("role")
+		require.False(t, ok)
+
+		_, ok = Meta[int](r, "role")
+		require.False(t, ok)
+	})
+
+	testCases := map[string]struct {
+		path          string
+		role          string
+		authenticated bool
+	}{
+		"inherited from group":       {path: "/api/me", role: "user", authenticated: true},
+		"overridden by nested group": {path: "/api/admin/users", role: "admin", authenticated: true},
+		"overridden by route":        {path: "/api/admin/public", role: "admin", authenticated: false},
+		"no metadata":                {path: "/", role: "", authenticated: false},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			role, authenticated = "", false
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tc.path, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, http.StatusOK, res.Code)
+			require.Equal(t, tc.role, role)
+			require.Equal(t, tc.authenticated, authenticated)
+		})
+	}
+}
+
 func TestRouter_UseAfterRoute(t *testing.T) {
 	router := New(WithBasicRequestContext)
 
-	router.Get("/hello", func(ctx context.Context, r *RootRequestContext) {})
-	require.PanicsWithValue(t, "Use can only be called before routes are defined", func() {
-		router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {})
+	var chain []string
+	track := func(name string) func(context.Context, *RootRequestContext, Handler[*RootRequestContext]) {
+		return func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+			chain = append(chain, name)
+			next(ctx, r)
+		}
+	}
+
+	router.Use(track("first"))
+	group := router.Namespace("/api")
+	group.Get("/hello", func(ctx context.Context, r *RootRequestContext) {
+		chain = append(chain, "handler")
+	})
+	group.Use(track("group"))
+	router.Use(track("second"))
+	router.UseMetal(func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		chain = append(chain, "metal")
+		next.ServeHTTP(w, r)
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/hello", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, []string{"metal", "first", "second", "group", "handler"}, chain)
+}
+
+func TestRouter_UseRelative(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	var chain []string
+	track := func(name string) func(context.Context, *RootRequestContext, Handler[*RootRequestContext]) {
+		return func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+			chain = append(chain, name)
+			next(ctx, r)
+		}
+	}
+
+	router.UseBefore("auth", track("before auth 1"), track("before auth 2"))
+	router.UseAfter("auth", track("after auth 1"))
+	router.Use(track("logger"))
+	router.UseNamed("auth", track("auth"))
+	router.UseAfter("auth", track("after auth 2"))
+	router.UseAfter("metrics", track("after metrics"))
+	router.UseNamed("metrics", track("metrics"))
+
+	group := router.Namespace("/api")
+	group.UseNamed("csrf", track("csrf"))
+	group.UseBefore("csrf", track("before csrf"))
+	group.Get("/hello", func(ctx context.Context, r *RootRequestContext) {
+		chain = append(chain, "handler")
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/hello", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, []string{
+		"logger",
+		"before auth 1",
+		"before auth 2",
+		"auth",
+		"after auth 1",
+		"after auth 2",
+		"metrics",
+		"after metrics",
+		"before csrf",
+		"csrf",
+		"handler",
+	}, chain)
+
+	routes := router.Routes()
+	require.Equal(t, "auth", routes[0].Middleware[3])
+	require.Equal(t, "csrf", routes[0].Middleware[9])
+}
+
+func TestRouter_UseRelativeMissing(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.UseAfter("auth", func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {})
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {})
+
+	require.PanicsWithValue(t, `middleware named "auth" passed to UseAfter is not registered`, router.Freeze)
+
+	// The router is left unfrozen, so later requests panic the same way
+	// instead of being served without a handler.
+	require.PanicsWithValue(t, `middleware named "auth" passed to UseAfter is not registered`, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	router.UseNamed("auth", func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) { next(ctx, r) })
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	router = New(WithBasicRequestContext)
+	group := router.Group()
+	group.UseBefore("csrf", func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {})
+	group.Get("/", func(ctx context.Context, r *RootRequestContext) {})
+	require.PanicsWithValue(t, `middleware named "csrf" passed to UseBefore is not registered`, router.Freeze)
+	require.PanicsWithValue(t, `middleware named "csrf" passed to UseBefore is not registered`, router.Freeze)
+
+	router = New(WithBasicRequestContext)
+	router.UseNamed("auth", func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {})
+	require.PanicsWithValue(t, `middleware named "auth" is already registered`, func() {
+		router.UseNamed("auth", func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {})
 	})
 }
 
@@ -206,6 +556,450 @@ func TestRouter_Wildcard_PanicBug(t *testing.T) {
 	require.Equal(t, "Not found!", res.Body.String())
 }
 
+func TestRouter_Backtracking(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	router.Get("/users/new/edit", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("edit"))
+	})
+	router.Get("/users/:id/settings", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("settings " + r.Params()["id"]))
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users/new/settings", nil)
+
+	require.NotPanics(t, func() {
+		router.ServeHTTP(res, req)
+	})
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "settings new", res.Body.String())
+}
+
+func TestRouter_WildcardParams(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	router.Get("/files/*path", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte(r.Params()["path"]))
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/files/foo/bar.txt", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "foo/bar.txt", res.Body.String())
+}
+
+func TestRouter_ConstrainedParams(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	router.Get("/items/:id<int>", func(ctx context.Context, r *RootRequestContext) {
+		id, err := r.ParamInt("id")
+		require.NoError(t, err)
+
+		_, _ = r.Response().Write([]byte(fmt.Sprintf("item %d", id+1)))
+	})
+	router.Get("/items/:slug<[a-z0-9-]+>", func(ctx context.Context, r *RootRequestContext) {
+		_, err := r.ParamInt("slug")
+		require.Error(t, err)
+
+		_, err = r.ParamInt("id")
+		require.ErrorIs(t, err, ErrParamNotFound)
+
+		_, _ = r.Response().Write([]byte("slug " + r.Params()["slug"]))
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/items/41", nil)
+	router.ServeHTTP(res, req)
+	require.Equal(t, "item 42", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/items/my-item", nil)
+	router.ServeHTTP(res, req)
+	require.Equal(t, "slug my-item", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/items/My_Item", nil)
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouter_MultiParamSegments(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	router.Get("/files/:name.json", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("json " + r.Params()["name"]))
+	})
+	router.Get("/files/:name.:ext", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte(r.Params()["name"] + " " + r.Params()["ext"]))
+	})
+	router.Get("/files/:name", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("file " + r.Params()["name"]))
+	})
+	router.Get("/v:version<int>/items", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("version " + r.Params()["version"]))
+	})
+	router.Get("/:owner/:repo@:ref", func(ctx context.Context, r *RootRequestContext) {
+		params := r.Params()
+		_, _ = r.Response().Write([]byte(params["owner"] + " " + params["repo"] + " " + params["ref"]))
+	})
+	router.Get("/files/:base.:extension", func(ctx context.Context, r *RootRequestContext) {})
+
+	testCases := map[string]struct {
+		path string
+		body string
+		code int
+	}{
+		"literal extension":   {path: "/files/data.json", body: "json data", code: http.StatusOK},
+		"extension param":     {path: "/files/archive.tar.gz", body: "archive.tar gz", code: http.StatusOK},
+		"no extension":        {path: "/files/README", body: "file README", code: http.StatusOK},
+		"version prefix":      {path: "/v2/items", body: "version 2", code: http.StatusOK},
+		"version mismatch":    {path: "/vnext/items", code: http.StatusNotFound},
+		"repository ref":      {path: "/blakewilliams/fernet@v1.0", body: "blakewilliams fernet v1.0", code: http.StatusOK},
+		"missing ref":         {path: "/blakewilliams/fernet@", code: http.StatusNotFound},
+		"missing ref literal": {path: "/blakewilliams/fernet", code: http.StatusNotFound},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tc.path, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.code, res.Code)
+			if tc.code == http.StatusOK {
+				require.Equal(t, tc.body, res.Body.String())
+			}
+		})
+	}
+
+	var conflict *RouteConflictError
+	require.ErrorAs(t, router.Validate(), &conflict)
+	require.Equal(t, ConflictDuplicate, conflict.Kind)
+}
+
+func TestRouter_EscapedColons(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	router.Get("/v1/items::batchGet", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("batch"))
+	}).Name("batch")
+	router.Get("/v1/:id::archive", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("archive " + r.Param("id")))
+	})
+
+	testCases := map[string]struct {
+		path string
+		body string
+		code int
+	}{
+		"literal colon":          {path: "/v1/items:batchGet", body: "batch", code: http.StatusOK},
+		"prefix only":            {path: "/v1/itemsXYZ", code: http.StatusNotFound},
+		"escape is not matched":  {path: "/v1/items::batchGet", code: http.StatusNotFound},
+		"param and literal":      {path: "/v1/42:archive", body: "archive 42", code: http.StatusOK},
+		"param missing literal":  {path: "/v1/42archive", code: http.StatusNotFound},
+		"param with other colon": {path: "/v1/42:unarchive", code: http.StatusNotFound},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, tc.code, res.Code)
+			require.Equal(t, tc.body, res.Body.String())
+		})
+	}
+
+	url, err := router.URL("batch")
+	require.NoError(t, err)
+	require.Equal(t, "/v1/items:batchGet", url)
+	require.NoError(t, router.Validate())
+}
+
+func TestRouter_URL(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	handler := func(ctx context.Context, r *RootRequestContext) {}
+
+	router.Get("/", handler).Name("root")
+	router.Get("/users/:id<int>", handler).Name("user")
+	router.Get("/files/*path", handler).Name("file")
+	router.Get("/downloads/:name.:ext", handler).Name("download")
+
+	api := router.Namespace("/api")
+	api.Namespace("/v1").Get("/teams/:team/members/:member", handler).Name("member")
+
+	controller := NewController(api, &PostData{})
+	controller.Namespace("/posts").Get("/:id", func(context.Context, *RootRequestContext, *PostData) {}).Name("post")
+
+	tests := map[string]struct {
+		name   string
+		params []string
+		want   string
+		err    string
+	}{
+		"root":            {name: "root", want: "/"},
+		"param":           {name: "user", params: []string{"id", "5"}, want: "/users/5"},
+		"wildcard":        {name: "file", params: []string{"path", "a b/c?.txt"}, want: "/files/a%20b/c%3F.txt"},
+		"nested":          {name: "member", params: []string{"team", "a/b", "member", "fox"}, want: "/api/v1/teams/a%2Fb/members/fox"},
+		"controller":      {name: "post", params: []string{"id", "1"}, want: "/api/posts/1"},
+		"multi param":     {name: "download", params: []string{"name", "a b.tar", "ext", "gz"}, want: "/downloads/a%20b.tar.gz"},
+		"ambiguous param": {name: "download", params: []string{"name", "a", "ext", "tar.gz"}, err: `values ["a" "tar.gz"] for params ["name" "ext"] of route "download" are ambiguous`},
+		"missing param":   {name: "user", err: `missing param "id" for route "user"`},
+		"invalid param":   {name: "user", params: []string{"id", "fox"}, err: `invalid value "fox" for param "id" of route "user"`},
+		"unknown param":   {name: "user", params: []string{"id", "5", "foo", "bar"}, err: `unknown params provided for route "user"`},
+		"odd params":      {name: "user", params: []string{"id"}, err: `params for route "user" must be provided as key value pairs`},
+		"unknown route":   {name: "nope", err: `no route named "nope"`},
+		"unnamed by path": {name: "/users/:id<int>", err: `no route named "/users/:id<int>"`},
+	}
+
+	for testName, tc := range tests {
+		t.Run(testName, func(t *testing.T) {
+			got, err := router.URL(tc.name, tc.params...)
+
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRouter_Mount(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("x-middleware", "true")
+		next(context.WithValue(ctx, contextKey{}, "fernet"), r)
+	})
+
+	router.Get("/admin/override", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("override"))
+	})
+	router.Mount("/admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, r.Context().Value(contextKey{}))
+	}))
+
+	tests := map[string]struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		"prefix":          {method: "GET", path: "/admin", code: http.StatusAccepted, body: "GET / fernet"},
+		"trailing slash":  {method: "GET", path: "/admin/", code: http.StatusAccepted, body: "GET / fernet"},
+		"nested":          {method: "POST", path: "/admin/users/1", code: http.StatusAccepted, body: "POST /users/1 fernet"},
+		"method priority": {method: "GET", path: "/admin/override", code: http.StatusOK, body: "override"},
+		"any method":      {method: "DELETE", path: "/admin/override", code: http.StatusAccepted, body: "DELETE /override fernet"},
+		"outside prefix":  {method: "GET", path: "/administrator", code: http.StatusNotFound, body: ""},
+	}
+
+	for testName, tc := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.code, res.Code)
+			require.Equal(t, tc.body, res.Body.String())
+			require.Equal(t, "true", res.Header().Get("x-middleware"))
+		})
+	}
+}
+
+func TestRouter_AnyMethodToken(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Match("ANY", "/any", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("any"))
+	})
+	router.Get("/any", func(ctx context.Context, r *RootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("ANY", "/any", nil))
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "any", res.Body.String())
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/any", nil))
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "ANY, GET, HEAD, OPTIONS", res.Header().Get("Allow"))
+}
+
+func TestRouter_MountRouter(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	subRouter := New(func(r RequestContext) *TrackingRequestContext {
+		return &TrackingRequestContext{RequestContext: r}
+	})
+	subRouter.Get("/hello/:name", func(ctx context.Context, r *TrackingRequestContext) {
+		r.Response().WriteHeader(http.StatusCreated)
+		_, _ = r.Response().Write([]byte("Hello " + r.Params()["name"]))
+	})
+
+	router.Namespace("/api").Mount("/v1", subRouter)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/hello/fox", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusCreated, res.Code)
+	require.Equal(t, "Hello fox", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/goodbye", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouter_Redirects(t *testing.T) {
+	handler := func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte(r.Request().URL.Path))
+	}
+
+	tests := map[string]struct {
+		opts     []Option
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		"clean path":                {method: "GET", path: "/users//5", code: http.StatusMovedPermanently, location: "/users/5"},
+		"clean dots":                {method: "GET", path: "/users/./posts/../5?foo=bar", code: http.StatusMovedPermanently, location: "/users/5?foo=bar"},
+		"clean post":                {method: "POST", path: "//users", code: http.StatusPermanentRedirect, location: "/users"},
+		"options asterisk":          {method: "OPTIONS", path: "*", code: http.StatusNotFound},
+		"clean disabled":            {opts: []Option{WithoutCleanPathRedirect()}, method: "GET", path: "/users//5", code: http.StatusNotFound},
+		"add trailing slash":        {method: "GET", path: "/teams", code: http.StatusMovedPermanently, location: "/teams/"},
+		"remove trailing slash":     {method: "POST", path: "/users/?q=1", code: http.StatusPermanentRedirect, location: "/users?q=1"},
+		"strict trailing slash":     {opts: []Option{WithTrailingSlash(TrailingSlashStrict)}, method: "GET", path: "/users/", code: http.StatusNotFound},
+		"case insensitive":          {opts: []Option{WithCaseInsensitiveRedirect()}, method: "GET", path: "/USERS/Fox/Posts", code: http.StatusMovedPermanently, location: "/users/Fox/posts"},
+		"case insensitive disabled": {method: "GET", path: "/USERS/Fox/Posts", code: http.StatusNotFound},
+		"exact match":               {method: "GET", path: "/users/5", code: http.StatusOK},
+	}
+
+	for testName, tc := range tests {
+		t.Run(testName, func(t *testing.T) {
+			router := New(WithBasicRequestContext, tc.opts...)
+			router.Get("/users", handler)
+			router.Post("/users", handler)
+			router.Get("/users/:id", handler)
+			router.Get("/users/:id/posts", handler)
+			router.Get("/teams/", handler)
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.code, res.Code)
+			require.Equal(t, tc.location, res.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRouter_EmptyResponsesWithReplacedContext(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		next(context.Background(), r)
+	})
+	router.Get("/users", func(ctx context.Context, r *RootRequestContext) {})
+
+	tests := map[string]struct {
+		method string
+		path   string
+		code   int
+		header string
+		value  string
+	}{
+		"not found":          {method: "GET", path: "/missing", code: http.StatusNotFound},
+		"trailing slash":     {method: "GET", path: "/users/", code: http.StatusMovedPermanently, header: "Location", value: "/users"},
+		"clean path":         {method: "GET", path: "//users", code: http.StatusMovedPermanently, header: "Location", value: "/users"},
+		"method not allowed": {method: "POST", path: "/users", code: http.StatusMethodNotAllowed, header: "Allow", value: "GET, HEAD, OPTIONS"},
+		"implicit options":   {method: "OPTIONS", path: "/users", code: http.StatusNoContent, header: "Allow", value: "GET, HEAD, OPTIONS"},
+	}
+
+	for testName, tc := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.code, res.Code)
+			if tc.header != "" {
+				require.Equal(t, tc.value, res.Header().Get(tc.header))
+			}
+		})
+	}
+}
+
+func TestRouter_NotFound(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("x-middleware", "true")
+		next(ctx, r)
+	})
+	router.NotFound(func(ctx context.Context, r *RootRequestContext) {
+		r.Response().Header().Set("Content-Type", "text/html")
+		r.Response().WriteHeader(http.StatusNotFound)
+		_, _ = r.Response().Write([]byte("<h1>Not Found</h1>"))
+	})
+	router.MethodNotAllowed(func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = r.Response().Write([]byte("try " + r.Response().Header().Get("Allow")))
+	})
+
+	api := router.Namespace("/api").Meta("error", "not found")
+	api.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("Content-Type", "application/json")
+		next(ctx, r)
+	})
+	api.NotFound(func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusNotFound)
+		message, _ := Meta[string](r, "error")
+		_, _ = fmt.Fprintf(r.Response(), `{"error": %q}`, message)
+	})
+	api.Get("/users", func(ctx context.Context, r *RootRequestContext) {})
+
+	tests := map[string]struct {
+		method      string
+		path        string
+		code        int
+		contentType string
+		body        string
+	}{
+		"site":                   {method: "GET", path: "/missing", code: http.StatusNotFound, contentType: "text/html", body: "<h1>Not Found</h1>"},
+		"root":                   {method: "GET", path: "/", code: http.StatusNotFound, contentType: "text/html", body: "<h1>Not Found</h1>"},
+		"api":                    {method: "GET", path: "/api/missing/deeply", code: http.StatusNotFound, contentType: "application/json", body: `{"error": "not found"}`},
+		"api prefix":             {method: "GET", path: "/api", code: http.StatusNotFound, contentType: "application/json", body: `{"error": "not found"}`},
+		"similar prefix":         {method: "GET", path: "/apis", code: http.StatusNotFound, contentType: "text/html", body: "<h1>Not Found</h1>"},
+		"method not allowed":     {method: "DELETE", path: "/api/users", code: http.StatusMethodNotAllowed, body: "try GET, HEAD, OPTIONS"},
+		"implicit options":       {method: "OPTIONS", path: "/api/users", code: http.StatusNoContent, contentType: "application/json"},
+		"registered route found": {method: "GET", path: "/api/users", code: http.StatusOK, contentType: "application/json"},
+	}
+
+	for testName, tc := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.code, res.Code)
+			require.Equal(t, tc.contentType, res.Header().Get("Content-Type"))
+			require.Equal(t, tc.body, res.Body.String())
+			require.Equal(t, "true", res.Header().Get("x-middleware"))
+		})
+	}
+
+	require.Len(t, router.Routes(), 1)
+}
+
 func WithBasicRequestContext(rctx RequestContext) *RootRequestContext {
 	return rctx.(*RootRequestContext)
 }
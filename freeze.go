@@ -0,0 +1,104 @@
+package fernet
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrFrozen is the error routers panic with when routes, middleware, or
+// metadata are registered after the router has been frozen.
+var ErrFrozen = errors.New("router is frozen")
+
+// mutable is implemented by types that register routes or middleware with a
+// router that can be frozen, so that groups can check the router they belong
+// to before being modified.
+type mutable interface {
+	// ensureMutable panics with an error wrapping ErrFrozen describing action
+	// if the router has been frozen.
+	ensureMutable(action string)
+}
+
+var _ mutable = (*Router[*RootRequestContext])(nil)
+
+// Freeze makes the router read-only, precompiling the middleware chain of
+// every route, the responses written by the router itself, and the metal
+// middleware of the router so they aren't rebuilt on every request.
+// Registering routes, middleware, or metadata after the router is frozen
+// panics with an error wrapping ErrFrozen.
+//
+// Freeze is called by the first call to ServeHTTP, so it only needs to be
+// called to catch late registrations before the router starts serving
+// requests. Calling Freeze more than once has no effect. Routes can still be
+// added, enabled, and disabled after the router is frozen using Update.
+//
+// Freeze panics if middleware was registered relative to a name that isn't
+// registered, in which case the router is left unfrozen.
+func (r *Router[T]) Freeze() {
+	if !r.frozen.Load() {
+		r.resolveMiddleware()
+	}
+
+	r.freezeOnce.Do(func() {
+		r.updateMu.Lock()
+		defer r.updateMu.Unlock()
+
+		r.frozen.Store(true)
+		r.middleware.freeze()
+		r.responders = compileEmptyResponses(r.middleware.resolve(), routerStatuses)
+		r.table.Store(r.compileTable())
+		r.handler = r.compileMetal(http.HandlerFunc(r.serveRoute))
+	})
+}
+
+// resolveMiddleware resolves the middleware stacks of the router and its
+// routes, panicking if middleware was registered relative to a name that
+// isn't registered. It's called before the router is frozen so that the
+// panic doesn't leave the router frozen without a handler.
+func (r *Router[T]) resolveMiddleware() {
+	r.middleware.resolve()
+
+	for _, route := range r.routes {
+		for _, stack := range route.stacks {
+			stack.resolve()
+		}
+	}
+}
+
+// ensureMutable implements the mutable interface.
+func (r *Router[T]) ensureMutable(action string) {
+	if r.frozen.Load() {
+		panic(fmt.Errorf("%w: cannot %s after the router has started serving requests", ErrFrozen, action))
+	}
+}
+
+// ensureUpdatable is like ensureMutable but allows changes made by the
+// function passed to Update, which is used for registering and toggling
+// routes.
+func (r *Router[T]) ensureUpdatable(action string) {
+	if !r.updating.Load() {
+		r.ensureMutable(action)
+	}
+}
+
+// compileMetal returns handler wrapped by the metal middleware of the router.
+func (r *Router[T]) compileMetal(handler http.Handler) http.Handler {
+	for i := len(r.metal) - 1; i >= 0; i-- {
+		next := handler
+		m := r.metal[i]
+
+		handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			m(rw, req, next)
+		})
+	}
+
+	return handler
+}
+
+// ensureMutable calls ensureMutable on registerable if it implements the
+// mutable interface.
+func ensureMutable[T RequestContext](registerable Registerable[T], action string) {
+	if m, ok := registerable.(mutable); ok {
+		m.ensureMutable(action)
+	}
+}
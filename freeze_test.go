@@ -0,0 +1,124 @@
+package fernet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Freeze(t *testing.T) {
+	handler := func(context.Context, *RootRequestContext) {}
+	middleware := func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		next(ctx, r)
+	}
+
+	tests := map[string]struct {
+		register func(router *Router[*RootRequestContext], group *Group[*RootRequestContext], route *Route[*RootRequestContext], controller *Controller[*RootRequestContext, *PostData])
+		want     string
+	}{
+		"route": {
+			register: func(router *Router[*RootRequestContext], _ *Group[*RootRequestContext], _ *Route[*RootRequestContext], _ *Controller[*RootRequestContext, *PostData]) {
+				router.Get("/late", handler)
+			},
+			want: "router is frozen: cannot register route GET /late after the router has started serving requests",
+		},
+		"group route": {
+			register: func(_ *Router[*RootRequestContext], group *Group[*RootRequestContext], _ *Route[*RootRequestContext], _ *Controller[*RootRequestContext, *PostData]) {
+				group.Post("/late", handler)
+			},
+			want: "router is frozen: cannot register route POST /api/late after the router has started serving requests",
+		},
+		"router middleware": {
+			register: func(router *Router[*RootRequestContext], _ *Group[*RootRequestContext], _ *Route[*RootRequestContext], _ *Controller[*RootRequestContext, *PostData]) {
+				router.Use(middleware)
+			},
+			want: "router is frozen: cannot register middleware after the router has started serving requests",
+		},
+		"group middleware": {
+			register: func(_ *Router[*RootRequestContext], group *Group[*RootRequestContext], _ *Route[*RootRequestContext], _ *Controller[*RootRequestContext, *PostData]) {
+				group.Use(middleware)
+			},
+			want: "router is frozen: cannot register group middleware after the router has started serving requests",
+		},
+		"group metadata": {
+			register: func(_ *Router[*RootRequestContext], group *Group[*RootRequestContext], _ *Route[*RootRequestContext], _ *Controller[*RootRequestContext, *PostData]) {
+				group.Meta("role", "admin")
+			},
+			want: "router is frozen: cannot set group metadata after the router has started serving requests",
+		},
+		"route middleware": {
+			register: func(_ *Router[*RootRequestContext], _ *Group[*RootRequestContext], route *Route[*RootRequestContext], _ *Controller[*RootRequestContext, *PostData]) {
+				route.Use(middleware)
+			},
+			want: "router is frozen: cannot register middleware for route GET /api/users after the router has started serving requests",
+		},
+		"controller middleware": {
+			register: func(_ *Router[*RootRequestContext], _ *Group[*RootRequestContext], _ *Route[*RootRequestContext], controller *Controller[*RootRequestContext, *PostData]) {
+				controller.Namespace("/posts").Use(middleware)
+			},
+			want: "router is frozen: cannot register controller middleware after the router has started serving requests",
+		},
+		"host": {
+			register: func(router *Router[*RootRequestContext], _ *Group[*RootRequestContext], _ *Route[*RootRequestContext], _ *Controller[*RootRequestContext, *PostData]) {
+				router.Host("api.example.com")
+			},
+			want: `router is frozen: cannot register host "api.example.com" after the router has started serving requests`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := New(WithBasicRequestContext)
+			group := router.Namespace("/api")
+			route := group.Get("/users", handler)
+			controller := NewController(group, &PostData{})
+
+			router.Freeze()
+
+			defer func() {
+				err, ok := recover().(error)
+				require.True(t, ok)
+				require.True(t, errors.Is(err, ErrFrozen))
+				require.EqualError(t, err, tc.want)
+			}()
+
+			tc.register(router, group, route, controller)
+		})
+	}
+}
+
+func TestRouter_FreezeOnServe(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	var chain []string
+	router.UseMetal(func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		chain = append(chain, "metal")
+		next.ServeHTTP(w, r)
+	})
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {
+		chain = append(chain, "handler")
+	}).Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		chain = append(chain, "route")
+		next(ctx, r)
+	})
+
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		router.ServeHTTP(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code)
+	}
+
+	require.Equal(t, []string{"metal", "route", "handler", "metal", "route", "handler"}, chain)
+	require.Panics(t, func() {
+		router.Get("/late", func(context.Context, *RootRequestContext) {})
+	})
+
+	// Freezing again has no effect.
+	require.NotPanics(t, router.Freeze)
+}
@@ -2,7 +2,10 @@ module github.com/blakewilliams/fernet
 
 go 1.21
 
-require github.com/stretchr/testify v1.8.4
+require (
+	github.com/google/uuid v1.6.0
+	github.com/stretchr/testify v1.8.4
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -9,7 +9,8 @@ type (
 	// Group is a collection of routes that share a common prefix and set of middleware.
 	Group[T RequestContext] struct {
 		prefix     string
-		middleware []func(context.Context, T, Handler[T])
+		middleware *middlewareStack[T]
+		metadata   map[string]any
 		parent     Registerable[T]
 	}
 )
@@ -22,47 +23,109 @@ func NewGroup[T RequestContext](parent Registerable[T], prefix string) *Group[T]
 	return &Group[T]{
 		prefix:     prefix,
 		parent:     parent,
-		middleware: make([]func(context.Context, T, Handler[T]), 0),
+		middleware: &middlewareStack[T]{},
+		metadata:   make(map[string]any),
 	}
 }
 
-func (g *Group[T]) RawMatch(method string, path string, fn Handler[T]) {
-	g.parent.RawMatch(method, joinURL(g.prefix, path), g.wrap(fn))
+// RawMatch implements the Registerable interface and registers a route with
+// the parent of the group.
+func (g *Group[T]) RawMatch(method string, path string, fn Handler[T]) *Route[T] {
+	route := g.parent.RawMatch(method, joinURL(g.prefix, path), fn)
+	route.addMiddlewareStack(g.middleware)
+	route.addGroupMetadata(g.metadata)
+
+	return route
 }
 
 // Match registers a route with the given method and path
-func (g *Group[T]) Match(method string, path string, fn Handler[T]) {
-	g.parent.RawMatch(method, joinURL(g.prefix, path), g.wrap(fn))
+func (g *Group[T]) Match(method string, path string, fn Handler[T]) *Route[T] {
+	return g.RawMatch(method, path, fn)
 }
 
 // Get registers a GET route with the given handler
-func (g *Group[T]) Get(path string, fn Handler[T]) {
-	g.Match(http.MethodGet, path, fn)
+func (g *Group[T]) Get(path string, fn Handler[T]) *Route[T] {
+	return g.Match(http.MethodGet, path, fn)
 }
 
 // Post registers a POST route with the given handler
-func (g *Group[T]) Post(path string, fn Handler[T]) {
-	g.Match(http.MethodPost, path, fn)
+func (g *Group[T]) Post(path string, fn Handler[T]) *Route[T] {
+	return g.Match(http.MethodPost, path, fn)
 }
 
 // Put registers a PUT route with the given handler
-func (g *Group[T]) Put(path string, fn Handler[T]) {
-	g.Match(http.MethodPut, path, fn)
+func (g *Group[T]) Put(path string, fn Handler[T]) *Route[T] {
+	return g.Match(http.MethodPut, path, fn)
 }
 
 // Patch registers a PATCH route with the given handler
-func (g *Group[T]) Patch(path string, fn Handler[T]) {
-	g.Match(http.MethodPatch, path, fn)
+func (g *Group[T]) Patch(path string, fn Handler[T]) *Route[T] {
+	return g.Match(http.MethodPatch, path, fn)
 }
 
 // Delete registers a DELETE route with the given handler
-func (g *Group[T]) Delete(path string, fn Handler[T]) {
-	g.Match(http.MethodDelete, path, fn)
+func (g *Group[T]) Delete(path string, fn Handler[T]) *Route[T] {
+	return g.Match(http.MethodDelete, path, fn)
+}
+
+// Mount registers an http.Handler that will handle requests of any method
+// for the given prefix, relative to the group, and all paths below it. The
+// prefix is stripped from the request path before the handler is called.
+func (g *Group[T]) Mount(prefix string, handler http.Handler) {
+	mount[T](g, prefix, handler)
+}
+
+// NotFound registers a handler that is called when no route matches a request
+// under the group's prefix. The group's middleware is run before the handler.
+func (g *Group[T]) NotFound(fn Handler[T]) {
+	registerFallback[T](g, notFoundMethod, "/", fn)
+}
+
+// MethodNotAllowed registers a handler that is called when the path of a
+// request under the group's prefix only matches routes registered for other
+// methods. The group's middleware is run before the handler.
+func (g *Group[T]) MethodNotAllowed(fn Handler[T]) {
+	registerFallback[T](g, methodNotAllowedMethod, "/", fn)
 }
 
 // Use registers middleware that will run before the handlers of this group and subgroups.
 func (g *Group[T]) Use(fns ...func(context.Context, T, Handler[T])) {
-	g.middleware = append(g.middleware, fns...)
+	g.ensureMutable("register group middleware")
+	g.middleware.use(fns...)
+}
+
+// UseNamed registers middleware like Use, with a name that other middleware
+// of the group can be inserted relative to using UseBefore and UseAfter.
+func (g *Group[T]) UseNamed(name string, fn func(context.Context, T, Handler[T])) {
+	g.ensureMutable("register group middleware")
+	g.middleware.useNamed(name, fn)
+}
+
+// UseBefore registers middleware that runs immediately before the group
+// middleware registered with UseNamed using the given name.
+func (g *Group[T]) UseBefore(name string, fns ...func(context.Context, T, Handler[T])) {
+	g.ensureMutable("register group middleware")
+	g.middleware.useRelative(name, false, fns...)
+}
+
+// UseAfter registers middleware that runs immediately after the group
+// middleware registered with UseNamed using the given name.
+func (g *Group[T]) UseAfter(name string, fns ...func(context.Context, T, Handler[T])) {
+	g.ensureMutable("register group middleware")
+	g.middleware.useRelative(name, true, fns...)
+}
+
+// Meta sets a metadata value that is inherited by every route registered with
+// the group and its subgroups. Subgroups and routes can override the value.
+func (g *Group[T]) Meta(key string, value any) *Group[T] {
+	g.ensureMutable("set group metadata")
+	g.metadata[key] = value
+	return g
+}
+
+// ensureMutable implements the mutable interface.
+func (g *Group[T]) ensureMutable(action string) {
+	ensureMutable(g.parent, action)
 }
 
 // Namespace returns a new route group with a prefix that will be applied to all
@@ -77,21 +140,3 @@ func (g *Group[T]) Namespace(prefix string) *Group[T] {
 func (g *Group[T]) Group() *Group[T] {
 	return NewGroup[T](g, "")
 }
-
-// wrap takes a Handler and ensures that this groups middleware is run before the handler is called
-func (g *Group[T]) wrap(fn Handler[T]) Handler[T] {
-	return func(ctx context.Context, r T) {
-		handler := fn
-
-		for i := len(g.middleware) - 1; i >= 0; i-- {
-			currentHandler := handler
-			middleware := g.middleware[i]
-
-			handler = func(ctx context.Context, r T) {
-				middleware(ctx, r, currentHandler)
-			}
-		}
-
-		handler(ctx, r)
-	}
-}
@@ -20,7 +20,7 @@ func TestGroup(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		routerFn func(string, Handler[*RootRequestContext])
+		routerFn func(string, Handler[*RootRequestContext]) *Route[*RootRequestContext]
 		method   string
 	}{
 		"GET":    {method: http.MethodGet, routerFn: group.Get},
@@ -30,10 +30,12 @@ func TestGroup(t *testing.T) {
 		"DELETE": {method: http.MethodDelete, routerFn: group.Delete},
 	}
 
+	for _, tc := range tests {
+		tc.routerFn("/foo", handler)
+	}
+
 	for testName, tc := range tests {
 		t.Run(testName, func(t *testing.T) {
-			tc.routerFn("/foo", handler)
-
 			res := httptest.NewRecorder()
 			req := httptest.NewRequest(tc.method, "/api/foo", nil)
 
@@ -122,3 +124,93 @@ func TestGroup_PrefixRoot(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, res.Code)
 }
+
+func TestGroup_Mount(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	group := router.Namespace("/api")
+	group.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("x-group", "api")
+		next(ctx, r)
+	})
+	group.Mount("/legacy", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/api/legacy/foo/bar", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "/foo/bar", res.Body.String())
+	require.Equal(t, "api", res.Header().Get("x-group"))
+}
+
+func TestGroup_ImplicitResponses(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/users", func(ctx context.Context, r *RootRequestContext) {})
+
+	api := router.Namespace("/api").Meta("scope", "api")
+	api.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		scope, _ := Meta[string](r, "scope")
+		r.Response().Header().Set("x-scope", scope)
+		next(ctx, r)
+	})
+	api.Get("/users", func(ctx context.Context, r *RootRequestContext) {}).
+		Meta("scope", "route").
+		Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+			r.Response().Header().Set("x-route", "true")
+			next(ctx, r)
+		})
+
+	tests := map[string]struct {
+		method string
+		path   string
+		code   int
+		scope  string
+	}{
+		"implicit options":   {method: "OPTIONS", path: "/api/users", code: http.StatusNoContent, scope: "api"},
+		"method not allowed": {method: "POST", path: "/api/users", code: http.StatusMethodNotAllowed, scope: "api"},
+		"outside group":      {method: "POST", path: "/users", code: http.StatusMethodNotAllowed},
+	}
+
+	for testName, tc := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.code, res.Code)
+			require.Equal(t, tc.scope, res.Header().Get("x-scope"))
+			require.Empty(t, res.Header().Get("x-route"))
+		})
+	}
+}
+
+func TestGroup_MethodNotAllowed(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/users", func(ctx context.Context, r *RootRequestContext) {})
+
+	api := router.Namespace("/api")
+	api.Get("/users", func(ctx context.Context, r *RootRequestContext) {})
+	api.MethodNotAllowed(func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = r.Response().Write([]byte(`{"error": "method not allowed"}`))
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/users", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "GET, HEAD, OPTIONS", res.Header().Get("Allow"))
+	require.Equal(t, `{"error": "method not allowed"}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/users", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "GET, HEAD, OPTIONS", res.Header().Get("Allow"))
+	require.Empty(t, res.Body.String())
+}
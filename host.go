@@ -0,0 +1,176 @@
+package fernet
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/blakewilliams/fernet/internal/radical"
+)
+
+type (
+	// hostPattern matches the Host header of a request. Patterns are made up
+	// of dot separated labels and an optional port, e.g.
+	// `:tenant.example.com:8080`. Labels prefixed with `:` capture a single
+	// label as a param and a leading `*` label matches one or more labels.
+	hostPattern struct {
+		raw    string
+		labels []string
+		port   string
+	}
+
+	// hostTree is a tree of routes that are only matched when the request
+	// Host header matches pattern.
+	hostTree[T RequestContext] struct {
+		pattern hostPattern
+		tree    *radical.Node[*Route[T]]
+	}
+
+	// matchedTree is a tree of routes that applies to a request, along with
+	// the params captured from the request host.
+	matchedTree[T RequestContext] struct {
+		tree   *radical.Node[*Route[T]]
+		params map[string]string
+	}
+
+	// hostRegistrar registers routes with a router that are restricted to a
+	// host pattern.
+	hostRegistrar[T RequestContext] struct {
+		router *Router[T]
+		host   *hostPattern
+	}
+)
+
+var _ Registerable[*RootRequestContext] = (*hostRegistrar[*RootRequestContext])(nil)
+
+// Host returns a new route group whose routes only match requests with a Host
+// header matching pattern. Routes registered with host groups take priority
+// over host-agnostic routes, which are used as a fallback.
+//
+// Patterns can contain named labels like `:tenant.example.com` whose values
+// are included in the request params, a leading wildcard label like
+// `*.example.com` to match any subdomain, and a port like
+// `api.example.com:8080`. If no port is provided, every port matches.
+func (r *Router[T]) Host(pattern string) *Group[T] {
+	r.ensureUpdatable(fmt.Sprintf("register host %q", pattern))
+
+	host := parseHostPattern(pattern)
+	r.hosts = append(r.hosts, &host)
+
+	return NewGroup[T](&hostRegistrar[T]{router: r, host: &host}, "")
+}
+
+// RawMatch implements the Registerable interface and registers the route with
+// the router, restricted to the host pattern.
+func (h *hostRegistrar[T]) RawMatch(method string, path string, fn Handler[T]) *Route[T] {
+	return h.router.addRoute(h.host, method, path, fn)
+}
+
+// ensureMutable implements the mutable interface.
+func (h *hostRegistrar[T]) ensureMutable(action string) {
+	h.router.ensureMutable(action)
+}
+
+// treesFor returns the trees of the table that apply to the request, starting
+// with the trees of matching host patterns in the order they were registered
+// and ending with the host-agnostic tree.
+func (t *routeTable[T]) treesFor(req *http.Request) []matchedTree[T] {
+	if len(t.hosts) == 0 {
+		return t.rootTrees
+	}
+
+	trees := make([]matchedTree[T], 0, 1)
+
+	for _, host := range t.hosts {
+		if params, ok := host.pattern.match(req.Host); ok {
+			trees = append(trees, matchedTree[T]{tree: host.tree, params: params})
+		}
+	}
+
+	return append(trees, t.rootTrees...)
+}
+
+func parseHostPattern(pattern string) hostPattern {
+	host := pattern
+	port := ""
+
+	if i := strings.LastIndex(pattern, ":"); i > 0 && isPort(pattern[i+1:]) {
+		host, port = pattern[:i], pattern[i+1:]
+	}
+
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if !strings.HasPrefix(label, ":") {
+			labels[i] = strings.ToLower(label)
+		}
+
+		if label == "" {
+			panic(fmt.Sprintf("host pattern %q contains an empty label", pattern))
+		}
+
+		if label == "*" && i != 0 {
+			panic(fmt.Sprintf("host pattern %q can only contain a wildcard as the first label", pattern))
+		}
+	}
+
+	return hostPattern{raw: pattern, labels: labels, port: port}
+}
+
+// match reports whether host satisfies the pattern, returning the params
+// captured from named labels.
+func (p hostPattern) match(host string) (map[string]string, bool) {
+	port := ""
+	if h, hostPort, err := net.SplitHostPort(host); err == nil {
+		host, port = h, hostPort
+	}
+
+	if p.port != "" && p.port != port {
+		return nil, false
+	}
+
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(host, ".")), ".")
+	patternLabels := p.labels
+
+	if patternLabels[0] == "*" {
+		patternLabels = patternLabels[1:]
+		if len(labels) <= len(patternLabels) {
+			return nil, false
+		}
+
+		labels = labels[len(labels)-len(patternLabels):]
+	}
+
+	if len(labels) != len(patternLabels) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, label := range patternLabels {
+		if strings.HasPrefix(label, ":") {
+			if labels[i] == "" {
+				return nil, false
+			}
+
+			params[label[1:]] = labels[i]
+		} else if label != labels[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func isPort(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,149 @@
+package fernet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostPattern(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		host    string
+		want    bool
+		params  map[string]string
+	}{
+		"static":               {pattern: "api.example.com", host: "api.example.com", want: true, params: map[string]string{}},
+		"static any port":      {pattern: "api.example.com", host: "api.example.com:8080", want: true, params: map[string]string{}},
+		"static case":          {pattern: "API.example.com", host: "api.EXAMPLE.com", want: true, params: map[string]string{}},
+		"static mismatch":      {pattern: "api.example.com", host: "admin.example.com", want: false},
+		"port":                 {pattern: "api.example.com:8080", host: "api.example.com:8080", want: true, params: map[string]string{}},
+		"port mismatch":        {pattern: "api.example.com:8080", host: "api.example.com:9090", want: false},
+		"port missing":         {pattern: "api.example.com:8080", host: "api.example.com", want: false},
+		"param":                {pattern: ":tenant.example.com", host: "acme.example.com", want: true, params: map[string]string{"tenant": "acme"}},
+		"param with port":      {pattern: ":tenant.example.com:443", host: "acme.example.com:443", want: true, params: map[string]string{"tenant": "acme"}},
+		"param too deep":       {pattern: ":tenant.example.com", host: "a.acme.example.com", want: false},
+		"param missing":        {pattern: ":tenant.example.com", host: "example.com", want: false},
+		"wildcard":             {pattern: "*.example.com", host: "acme.example.com", want: true, params: map[string]string{}},
+		"wildcard nested":      {pattern: "*.example.com", host: "a.b.example.com", want: true, params: map[string]string{}},
+		"wildcard apex":        {pattern: "*.example.com", host: "example.com", want: false},
+		"wildcard with param":  {pattern: "*.:tenant.example.com", host: "www.acme.example.com", want: true, params: map[string]string{"tenant": "acme"}},
+		"trailing dot in host": {pattern: "api.example.com", host: "api.example.com.", want: true, params: map[string]string{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			params, ok := parseHostPattern(tc.pattern).match(tc.host)
+
+			require.Equal(t, tc.want, ok)
+			require.Equal(t, tc.params, params)
+		})
+	}
+}
+
+func TestHostPattern_Invalid(t *testing.T) {
+	require.PanicsWithValue(t, `host pattern "api..com" contains an empty label`, func() {
+		parseHostPattern("api..com")
+	})
+
+	require.PanicsWithValue(t, `host pattern "api.*.com" can only contain a wildcard as the first label`, func() {
+		parseHostPattern("api.*.com")
+	})
+}
+
+func TestRouter_Host(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("x-middleware", "true")
+		next(ctx, r)
+	})
+
+	write := func(s string) Handler[*RootRequestContext] {
+		return func(ctx context.Context, r *RootRequestContext) {
+			_, _ = r.Response().Write([]byte(s))
+		}
+	}
+
+	api := router.Host("api.example.com")
+	api.Get("/", write("api root"))
+	api.Namespace("/v1").Get("/users/:id", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("api user " + r.Params()["id"]))
+	})
+
+	router.Host("admin.example.com:8443").Get("/", write("admin root"))
+
+	tenant := router.Host(":tenant.example.com")
+	tenant.Get("/", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("tenant " + r.Params()["tenant"]))
+	})
+	tenant.NotFound(func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusNotFound)
+		_, _ = r.Response().Write([]byte("tenant not found"))
+	})
+
+	router.Host("*.example.org").Get("/", write("wildcard root"))
+
+	router.Get("/", write("root"))
+	router.Get("/about", write("about"))
+
+	tests := map[string]struct {
+		host string
+		path string
+		code int
+		body string
+	}{
+		"api":                 {host: "api.example.com", path: "/", code: http.StatusOK, body: "api root"},
+		"api nested":          {host: "api.example.com:3000", path: "/v1/users/5", code: http.StatusOK, body: "api user 5"},
+		"api fallback":        {host: "api.example.com", path: "/about", code: http.StatusOK, body: "about"},
+		"admin port":          {host: "admin.example.com:8443", path: "/", code: http.StatusOK, body: "admin root"},
+		"admin wrong port":    {host: "admin.example.com", path: "/", code: http.StatusOK, body: "tenant admin"},
+		"tenant":              {host: "acme.example.com", path: "/", code: http.StatusOK, body: "tenant acme"},
+		"tenant not found":    {host: "acme.example.com", path: "/missing", code: http.StatusNotFound, body: "tenant not found"},
+		"wildcard":            {host: "www.blog.example.org", path: "/", code: http.StatusOK, body: "wildcard root"},
+		"unknown host":        {host: "example.net", path: "/", code: http.StatusOK, body: "root"},
+		"unknown host no 404": {host: "example.net", path: "/missing", code: http.StatusNotFound, body: ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Host = tc.host
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.code, res.Code)
+			require.Equal(t, tc.body, res.Body.String())
+			require.Equal(t, "true", res.Header().Get("x-middleware"))
+		})
+	}
+
+	routes := router.Routes()
+	require.Equal(t, "api.example.com", routes[0].Host)
+	require.Equal(t, "/v1/users/:id", routes[1].Path)
+	require.Equal(t, "", routes[len(routes)-1].Host)
+}
+
+func TestRouter_HostMethodNotAllowed(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Host("api.example.com").Post("/users", func(ctx context.Context, r *RootRequestContext) {})
+	router.Get("/users", func(ctx context.Context, r *RootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/users", nil)
+	req.Host = "api.example.com"
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header().Get("Allow"))
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/users", nil)
+	req.Host = "www.example.com"
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	require.Equal(t, "GET, HEAD, OPTIONS", res.Header().Get("Allow"))
+}
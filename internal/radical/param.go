@@ -0,0 +1,374 @@
+package radical
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// constraints are the named patterns that can be used in place of a regular
+// expression when constraining a param, e.g. `:id<int>`.
+var constraints = map[string]string{
+	"int":  `-?[0-9]+`,
+	"uuid": `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// matchAllExprs are constraint expressions that match any non-empty segment.
+var matchAllExprs = map[string]bool{
+	`.*`:     true,
+	`.+`:     true,
+	`[^/]*`:  true,
+	`[^/]+`:  true,
+	`.*?`:    true,
+	`.+?`:    true,
+	`[^/]*?`: true,
+	`[^/]+?`: true,
+}
+
+// Pattern is a parsed path segment containing one or more params. Params can
+// make up the entire segment, like `:id`, or be mixed with literal text, like
+// `:name.:ext`, `v:version`, or `:repo@:ref`.
+type Pattern struct {
+	// Key identifies the pattern independent of its param names, e.g. `:.:`
+	// for `:name.:ext`. Patterns with the same key match the same segments.
+	Key string
+	// Names are the names of the params in the order they appear.
+	Names []string
+
+	// literals are the literal text surrounding the params. There is always
+	// one more literal than there are params.
+	literals []string
+	// constraints are the raw constraints of each param.
+	constraints []string
+	// matches reports whether a value satisfies the constraint of each param.
+	matches []func(string) bool
+	// re matches segments with literal text, capturing each param. It's nil
+	// when a single param makes up the entire segment.
+	re *regexp.Regexp
+}
+
+// IsPattern reports whether segment contains a param, i.e. a `:` that isn't
+// escaped as `::`.
+func IsPattern(segment string) bool {
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != ':' {
+			continue
+		}
+
+		if i+1 < len(segment) && segment[i+1] == ':' {
+			i++
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// Escape returns the segment matching the literal text, escaping each `:` as
+// `::` so it isn't parsed as a param.
+func Escape(text string) string {
+	return strings.ReplaceAll(text, ":", "::")
+}
+
+// Unescape returns the literal text matched by a segment without params,
+// replacing each escaped `::` with `:`.
+func Unescape(segment string) string {
+	return strings.ReplaceAll(segment, "::", ":")
+}
+
+// keyEscaper escapes literal text in pattern keys, so that literal colons
+// can't be mistaken for params.
+var keyEscaper = strings.NewReplacer(`\`, `\\`, ":", `\:`)
+
+// LiteralKey returns the key of a segment made up of literal text. Literal
+// keys never equal the Key of a Pattern.
+func LiteralKey(text string) string {
+	return keyEscaper.Replace(text)
+}
+
+// ParsePattern parses a segment containing one or more params. Each param
+// starts with `:` followed by a name made up of letters, digits, and
+// underscores, and an optional constraint in the form of `<constraint>`.
+// Literal colons are escaped as `::`, e.g. `:id::archive` matches `1:archive`.
+//
+// When literal text separates params, the earlier params match as much of the
+// segment as possible, so `:name.:ext` matches `archive.tar.gz` with a name of
+// `archive.tar` and an ext of `gz`. ParsePattern panics if the segment
+// contains a param without a name, params that are not separated by literal
+// text, or an invalid constraint.
+func ParsePattern(segment string) *Pattern {
+	pattern := &Pattern{}
+	key := strings.Builder{}
+	literal := strings.Builder{}
+
+	for i := 0; i < len(segment); {
+		if segment[i] != ':' {
+			literal.WriteByte(segment[i])
+			i++
+			continue
+		}
+
+		if i+1 < len(segment) && segment[i+1] == ':' {
+			literal.WriteByte(':')
+			i += 2
+			continue
+		}
+
+		if i > 0 && literal.Len() == 0 && len(pattern.Names) > 0 {
+			panic(fmt.Sprintf("params in segment %q must be separated by literal text", segment))
+		}
+
+		end := i + 1
+		for end < len(segment) && isNameByte(segment[end]) {
+			end++
+		}
+
+		if end == i+1 {
+			panic(fmt.Sprintf("param in segment %q is missing a name", segment))
+		}
+
+		if end < len(segment) && segment[end] == '<' {
+			end = constraintEnd(segment, end)
+		}
+
+		name, constraint, matches := ParseParam(segment[i:end])
+
+		pattern.literals = append(pattern.literals, literal.String())
+		pattern.Names = append(pattern.Names, name)
+		pattern.constraints = append(pattern.constraints, constraint)
+		pattern.matches = append(pattern.matches, matches)
+
+		key.WriteString(LiteralKey(literal.String()))
+		key.WriteByte(':')
+		if constraint != "" {
+			key.WriteString("<" + constraint + ">")
+		}
+
+		literal.Reset()
+		i = end
+	}
+
+	pattern.literals = append(pattern.literals, literal.String())
+	key.WriteString(LiteralKey(literal.String()))
+	pattern.Key = key.String()
+
+	if len(pattern.Names) > 1 || pattern.literals[0] != "" || pattern.literals[1] != "" {
+		pattern.re = pattern.compile(segment)
+	}
+
+	return pattern
+}
+
+// LiteralLen returns the length of the literal text in the pattern.
+func (p *Pattern) LiteralLen() int {
+	length := 0
+	for _, literal := range p.literals {
+		length += len(literal)
+	}
+
+	return length
+}
+
+// compile returns a regular expression matching the pattern, with a named
+// capture group for each param. Constraints are included in the expression so
+// that params are split in a way that satisfies them.
+func (p *Pattern) compile(segment string) *regexp.Regexp {
+	expr := strings.Builder{}
+	expr.WriteString("^")
+
+	for i, constraint := range p.constraints {
+		paramExpr := ".+"
+		if constraint != "" {
+			paramExpr = "(?:" + constraintExpr(constraint) + ")"
+		}
+
+		expr.WriteString(regexp.QuoteMeta(p.literals[i]))
+		expr.WriteString("(?P<p" + strconv.Itoa(i) + ">" + paramExpr + ")")
+	}
+
+	expr.WriteString(regexp.QuoteMeta(p.literals[len(p.literals)-1]))
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		panic(fmt.Sprintf("invalid segment %q: %s", segment, err))
+	}
+
+	return re
+}
+
+// Match reports whether segment matches the pattern, returning the value of
+// each param in the order of Names.
+func (p *Pattern) Match(segment string) ([]string, bool) {
+	if p.re == nil {
+		if !p.matches[0](segment) {
+			return nil, false
+		}
+
+		return []string{segment}, true
+	}
+
+	submatches := p.re.FindStringSubmatch(segment)
+	if submatches == nil {
+		return nil, false
+	}
+
+	values := make([]string, len(p.Names))
+	for i := range p.Names {
+		values[i] = submatches[p.re.SubexpIndex("p"+strconv.Itoa(i))]
+		if !p.matches[i](values[i]) {
+			return nil, false
+		}
+	}
+
+	return values, true
+}
+
+// Matches reports whether segment matches the pattern.
+func (p *Pattern) Matches(segment string) bool {
+	if p.re == nil {
+		return p.matches[0](segment)
+	}
+
+	_, ok := p.Match(segment)
+	return ok
+}
+
+// IsSingle reports whether the pattern is a single param making up the whole
+// segment, in which case the segment is the value of the param.
+func (p *Pattern) IsSingle() bool {
+	return p.re == nil
+}
+
+// MatchesParam reports whether value satisfies the constraint of the i-th
+// param of the pattern.
+func (p *Pattern) MatchesParam(i int, value string) bool {
+	return p.matches[i](value)
+}
+
+// Covers reports whether p matches every segment that other matches. Only
+// patterns with the same literal text are compared, and a param covers
+// another param when it's unconstrained, its constraint matches any segment,
+// or both constraints use the same expression.
+func (p *Pattern) Covers(other *Pattern) bool {
+	if len(p.literals) != len(other.literals) {
+		return false
+	}
+
+	for i, literal := range p.literals {
+		if literal != other.literals[i] {
+			return false
+		}
+	}
+
+	for i, constraint := range p.constraints {
+		if constraint == "" || matchAllExprs[constraintExpr(constraint)] {
+			continue
+		}
+
+		if other.constraints[i] == "" || constraintExpr(constraint) != constraintExpr(other.constraints[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Build returns the segment for the given param values, in the order of
+// Names, with each value transformed by escape. False is returned if a value
+// does not satisfy its constraint, or if the segment would not match the
+// same values, e.g. a name of `a` and ext of `b.c` for `:name.:ext`.
+func (p *Pattern) Build(values []string, escape func(string) string) (string, bool) {
+	if len(values) != len(p.Names) {
+		return "", false
+	}
+
+	raw := strings.Builder{}
+	escaped := strings.Builder{}
+
+	for i, value := range values {
+		raw.WriteString(p.literals[i] + value)
+		escaped.WriteString(p.literals[i] + escape(value))
+	}
+
+	raw.WriteString(p.literals[len(p.literals)-1])
+	escaped.WriteString(p.literals[len(p.literals)-1])
+
+	matched, ok := p.Match(raw.String())
+	if !ok {
+		return "", false
+	}
+
+	for i, value := range values {
+		if matched[i] != value {
+			return "", false
+		}
+	}
+
+	return escaped.String(), true
+}
+
+// ParseParam parses a named segment in the form of `:name` or
+// `:name<constraint>` and returns the name of the param, the raw constraint,
+// and a function reporting whether a path segment satisfies the constraint.
+//
+// The constraint can either be the name of a built-in constraint (`int` or
+// `uuid`) or a regular expression that must match the entire path segment.
+// Empty segments never satisfy a param. ParseParam panics if the constraint is
+// not a valid regular expression.
+func ParseParam(segment string) (string, string, func(string) bool) {
+	name := strings.TrimPrefix(segment, ":")
+
+	start := strings.Index(name, "<")
+	if start == -1 || !strings.HasSuffix(name, ">") {
+		return name, "", func(s string) bool { return s != "" }
+	}
+
+	constraint := name[start+1 : len(name)-1]
+	name = name[:start]
+
+	re, err := regexp.Compile("^(?:" + constraintExpr(constraint) + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("invalid constraint for param %q: %s", name, err))
+	}
+
+	return name, constraint, func(s string) bool { return s != "" && re.MatchString(s) }
+}
+
+// constraintExpr returns the regular expression for constraint, resolving
+// built-in constraints like `int`.
+func constraintExpr(constraint string) string {
+	if expr, ok := constraints[constraint]; ok {
+		return expr
+	}
+
+	return constraint
+}
+
+// constraintEnd returns the index after the `>` closing the constraint that
+// starts at start, allowing nested angle brackets like those of named capture
+// groups. If the constraint is not closed the end of segment is returned.
+func constraintEnd(segment string, start int) int {
+	depth := 0
+
+	for i := start; i < len(segment); i++ {
+		switch segment[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return len(segment)
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
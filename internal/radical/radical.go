@@ -3,11 +3,16 @@
 package radical
 
 import (
+	"errors"
 	"fmt"
-	"reflect"
+	"sort"
 	"strings"
 )
 
+// UnconstrainedKey is the pattern key of a segment made up of a single
+// unconstrained param, e.g. `:name`.
+const UnconstrainedKey = ":"
+
 type (
 	// Node represents a node in the tree
 	Node[T any] struct {
@@ -17,122 +22,217 @@ type (
 		value T
 		// Is there a value set?
 		isSet bool
-		// The children of this node.
+		// The static children of this node.
 		children map[string]*Node[T]
+		// The children matching a single non-empty segment, e.g. `:name` or
+		// `:name.:ext`. Constrained params and params mixed with literal text
+		// are ordered before unconstrained params.
+		params []*Node[T]
+		// The key of a param node's pattern, used to identify it when adding.
+		key string
+		// The length of the literal text in a param node's pattern.
+		literalLen int
+		// Reports whether a segment satisfies the param node's pattern.
+		matches func(string) bool
+		// The child matching all remaining segments, e.g. `*path`.
+		wildcard *Node[T]
 	}
 )
 
 // New returns a new root Radix tree node
 func New[T any]() *Node[T] {
+	return newNode[T]("")
+}
+
+func newNode[T any](segment string) *Node[T] {
 	return &Node[T]{
-		segment:  "",
+		segment:  segment,
 		children: make(map[string]*Node[T], 0),
 	}
 }
 
-// Add adds a new node to the tree.
-func (n *Node[T]) Add(segments []string, value T) {
+// ErrDuplicate is returned when adding a value for segments that already have
+// a value in the tree.
+var ErrDuplicate = errors.New("duplicate value")
+
+// Add adds a new node to the tree. Static segments can contain literal colons
+// escaped as `::`. If the segments already have a value, the existing value is
+// kept and an error wrapping ErrDuplicate is returned.
+func (n *Node[T]) Add(segments []string, value T) error {
 	currentSegment := n
 
 	for i, segment := range segments {
-		if strings.HasPrefix(segment, ":") {
-			child, ok := currentSegment.children[":named"]
-
-			if ok {
-				currentSegment = child
-				continue
-			}
-
-			currentSegment.children[":named"] = &Node[T]{
-				segment:  ":named",
-				children: make(map[string]*Node[T], 0),
-			}
-
-			currentSegment = currentSegment.children[":named"]
-			continue
-		}
-
 		if strings.HasPrefix(segment, "*") {
 			if i != len(segments)-1 {
 				panic("wildcard segments must be the last segment in a path")
 			}
 
-			_, ok := currentSegment.children["*"]
-			if ok {
-				panic("wildcard segments can only be used once in a path")
-			}
-
-			currentSegment.children["*"] = &Node[T]{
-				segment:  "*",
-				children: make(map[string]*Node[T], 0),
+			if currentSegment.wildcard == nil {
+				currentSegment.wildcard = newNode[T]("*")
 			}
-
-			currentSegment = currentSegment.children["*"]
+			currentSegment = currentSegment.wildcard
 
 			break
 		}
 
-		child, ok := currentSegment.children[segment]
-		if ok {
-			currentSegment = child
+		if IsPattern(segment) {
+			currentSegment = currentSegment.addParam(segment)
 			continue
 		}
 
-		currentSegment.children[segment] = &Node[T]{
-			segment:  segment,
-			children: make(map[string]*Node[T], 0),
+		segment = Unescape(segment)
+		child, ok := currentSegment.children[segment]
+		if !ok {
+			child = newNode[T](segment)
+			currentSegment.children[segment] = child
 		}
 
-		currentSegment = currentSegment.children[segment]
+		currentSegment = child
 	}
 
 	if currentSegment.isSet {
-		panic(fmt.Sprintf("duplicate route detected: %v", segments))
+		return fmt.Errorf("%w: %s", ErrDuplicate, strings.Join(segments, "/"))
 	}
 
 	currentSegment.value = value
 	currentSegment.isSet = true
+
+	return nil
+}
+
+// addParam returns the param child matching the pattern of segment, creating
+// it if it does not exist yet.
+func (n *Node[T]) addParam(segment string) *Node[T] {
+	pattern := ParsePattern(segment)
+
+	for _, child := range n.params {
+		if child.key == pattern.Key {
+			return child
+		}
+	}
+
+	child := newNode[T](":named")
+	child.key = pattern.Key
+	child.literalLen = pattern.LiteralLen()
+	child.matches = pattern.Matches
+
+	// Keep unconstrained params last so that constraints and literal text get
+	// a chance to match first. Params with more literal text are more
+	// specific, so they're tried before params with less.
+	if pattern.Key != UnconstrainedKey {
+		for i, param := range n.params {
+			if param.key == UnconstrainedKey || param.literalLen < child.literalLen {
+				n.params = append(n.params[:i], append([]*Node[T]{child}, n.params[i:]...)...)
+				return child
+			}
+		}
+	}
+
+	n.params = append(n.params, child)
+
+	return child
 }
 
 // Value searches the tree for a node matching the provided segments. If a match
 // is found it returns true and the associated value T. If a match is not found
 // it returns false and the zero value of T.
+//
+// When more than one node could match, static segments take priority over
+// segments mixing params with literal text, ordered by the length of the
+// literal text, then constrained named segments, then unconstrained named
+// segments, and finally wildcards. If a higher priority branch fails to match
+// the remaining segments, the search backtracks and tries the next branch.
 func (n *Node[T]) Value(segments []string) (bool, T) {
-	currentNode := n
-	var lastWildcard *Node[T]
-
-	for _, segment := range segments {
-		// hold onto the last wildcard node we've seen in case we need it later
-		// if routes don't match
-		if wildcard, ok := currentNode.children["*"]; ok {
-			lastWildcard = wildcard
+	if node := n.find(segments, false); node != nil {
+		return true, node.value
+	}
+
+	var zero T
+	return false, zero
+}
+
+// Child returns the static child of the node for segment, or nil if there is
+// none.
+func (n *Node[T]) Child(segment string) *Node[T] {
+	return n.children[segment]
+}
+
+// ValueFold is like Value but static segments are matched case-insensitively.
+// Exact matches are preferred over case-insensitive matches.
+func (n *Node[T]) ValueFold(segments []string) (bool, T) {
+	if node := n.find(segments, true); node != nil {
+		return true, node.value
+	}
+
+	var zero T
+	return false, zero
+}
+
+// find performs a depth-first search for the node matching segments. If fold
+// is true static segments are compared case-insensitively.
+func (n *Node[T]) find(segments []string, fold bool) *Node[T] {
+	if len(segments) == 0 {
+		if n.isSet {
+			return n
 		}
 
-		child, ok := currentNode.children[segment]
-		if ok {
-			currentNode = child
-			continue
+		return nil
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	if child, ok := n.children[segment]; ok {
+		if node := child.find(rest, fold); node != nil {
+			return node
 		}
+	}
 
-		child, ok = currentNode.children[":named"]
-		if !ok {
-			if lastWildcard != nil {
-				return true, lastWildcard.value
+	if fold {
+		keys := make([]string, 0, len(n.children))
+		for key := range n.children {
+			if key != segment && strings.EqualFold(key, segment) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if node := n.children[key].find(rest, fold); node != nil {
+				return node
 			}
+		}
+	}
 
-			return false, reflect.Zero(reflect.TypeOf(n.value)).Interface().(T)
+	for _, param := range n.params {
+		if !param.matches(segment) {
+			continue
 		}
 
-		currentNode = child
+		if node := param.find(rest, fold); node != nil {
+			return node
+		}
 	}
 
-	if currentNode.isSet {
-		return true, currentNode.value
+	if n.wildcard != nil && n.wildcard.isSet {
+		return n.wildcard
 	}
 
-	if lastWildcard != nil {
-		return true, lastWildcard.value
+	return nil
+}
+
+// ValuesAcross treats the first level of the tree as a wildcard and searches
+// each static child of the node for a value matching the provided segments.
+// The returned map is keyed by the segment of the child the value was found
+// under. This is useful when the first segment is a discriminator, like an
+// HTTP method, and every subtree needs to be checked for a path.
+func (n *Node[T]) ValuesAcross(segments []string) map[string]T {
+	values := make(map[string]T)
+
+	for segment, child := range n.children {
+		if ok, value := child.Value(segments); ok {
+			values[segment] = value
+		}
 	}
 
-	return false, currentNode.value
+	return values
 }
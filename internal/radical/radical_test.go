@@ -1,6 +1,9 @@
 package radical_test
 
 import (
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/blakewilliams/fernet/internal/radical"
@@ -150,10 +153,10 @@ func TestNode_DynamicEdgeCases(t *testing.T) {
 	root := radical.New[int]()
 	// Simulate /foo/bar/baz
 	root.Add([]string{"foo", ":bar", "baz"}, 1)
-	// duplicate routes should panic
-	require.Panics(t, func() {
-		root.Add([]string{"foo", ":bar", "baz"}, 2)
-	})
+	// duplicate routes should return an error
+	err := root.Add([]string{"foo", ":bar", "baz"}, 2)
+	require.ErrorIs(t, err, radical.ErrDuplicate)
+	require.EqualError(t, err, "duplicate value: foo/:bar/baz")
 	ok, value := root.Value([]string{"foo", "bar", "baz"})
 	require.True(t, ok)
 	require.Equal(t, 1, value)
@@ -164,3 +167,341 @@ func TestNode_DynamicEdgeCases(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, 3, value)
 }
+
+func TestNode_ValuesAcross(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"GET", "foo", ":name"}, 1)
+	root.Add([]string{"POST", "foo", ":name"}, 2)
+	root.Add([]string{"PUT", "foo"}, 3)
+	root.Add([]string{"DELETE", "*"}, 4)
+
+	values := root.ValuesAcross([]string{"foo", "bar"})
+	require.Equal(t, map[string]int{"GET": 1, "POST": 2, "DELETE": 4}, values)
+
+	values = root.ValuesAcross([]string{"foo"})
+	require.Equal(t, map[string]int{"PUT": 3, "DELETE": 4}, values)
+}
+
+func TestNode_Backtracking(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"users", "new", "edit"}, 1)
+	root.Add([]string{"users", ":id", "settings"}, 2)
+	root.Add([]string{"users", "*"}, 3)
+
+	ok, value := root.Value([]string{"users", "new", "edit"})
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	ok, value = root.Value([]string{"users", "new", "settings"})
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	ok, value = root.Value([]string{"users", "new", "other"})
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+}
+
+func TestNode_EmptyParam(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"users", ":id"}, 1)
+
+	ok, _ := root.Value([]string{"users", ""})
+	require.False(t, ok)
+}
+
+func TestNode_Constraints(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"items", ":id<int>"}, 1)
+	root.Add([]string{"items", ":slug<[a-z0-9-]+>"}, 2)
+	root.Add([]string{"items", ":uuid<uuid>", "edit"}, 3)
+	root.Add([]string{"items", ":any", "edit"}, 4)
+
+	ok, value := root.Value([]string{"items", "42"})
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	ok, value = root.Value([]string{"items", "hello-world"})
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	ok, _ = root.Value([]string{"items", "Hello"})
+	require.False(t, ok)
+
+	ok, value = root.Value([]string{"items", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "edit"})
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+
+	ok, value = root.Value([]string{"items", "42", "edit"})
+	require.True(t, ok)
+	require.Equal(t, 4, value)
+}
+
+func TestNode_Patterns(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"files", ":name"}, 1)
+	root.Add([]string{"files", ":name.:ext"}, 2)
+	root.Add([]string{"files", ":name.json"}, 3)
+	root.Add([]string{"v:version<int>", "items"}, 4)
+	root.Add([]string{":owner", ":repo@:ref"}, 5)
+
+	ok, value := root.Value([]string{"files", "README"})
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	ok, value = root.Value([]string{"files", "main.go"})
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	ok, value = root.Value([]string{"files", "data.json"})
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+
+	ok, value = root.Value([]string{"v2", "items"})
+	require.True(t, ok)
+	require.Equal(t, 4, value)
+
+	ok, _ = root.Value([]string{"vnext", "items"})
+	require.False(t, ok)
+
+	ok, value = root.Value([]string{"blakewilliams", "fernet@main"})
+	require.True(t, ok)
+	require.Equal(t, 5, value)
+
+	require.ErrorIs(t, root.Add([]string{"files", ":base.:extension"}, 6), radical.ErrDuplicate)
+}
+
+func TestNode_EscapedColons(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"v1", "items::batchGet"}, 1)
+	root.Add([]string{"v1", "items:id"}, 2)
+
+	ok, value := root.Value([]string{"v1", "items:batchGet"})
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	ok, value = root.Value([]string{"v1", "itemsXYZ"})
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+}
+
+func TestParsePattern(t *testing.T) {
+	pattern := radical.ParsePattern(":name.:ext")
+	require.Equal(t, ":.:", pattern.Key)
+	require.Equal(t, []string{"name", "ext"}, pattern.Names)
+
+	values, ok := pattern.Match("archive.tar.gz")
+	require.True(t, ok)
+	require.Equal(t, []string{"archive.tar", "gz"}, values)
+
+	_, ok = pattern.Match(".gz")
+	require.False(t, ok)
+
+	pattern = radical.ParsePattern(":major<int>.:rest")
+	require.Equal(t, ":<int>.:", pattern.Key)
+
+	values, ok = pattern.Match("1.2.x")
+	require.True(t, ok)
+	require.Equal(t, []string{"1", "2.x"}, values)
+
+	pattern = radical.ParsePattern("v:version")
+	require.Equal(t, "v:", pattern.Key)
+
+	values, ok = pattern.Match("v10")
+	require.True(t, ok)
+	require.Equal(t, []string{"10"}, values)
+
+	segment, ok := pattern.Build([]string{"1 0"}, func(s string) string { return strings.ReplaceAll(s, " ", "%20") })
+	require.True(t, ok)
+	require.Equal(t, "v1%200", segment)
+
+	pattern = radical.ParsePattern(":name.:ext")
+	_, ok = pattern.Build([]string{"a", "b.c"}, func(s string) string { return s })
+	require.False(t, ok)
+
+	pattern = radical.ParsePattern(":id")
+	require.Equal(t, ":", pattern.Key)
+	require.Equal(t, []string{"id"}, pattern.Names)
+
+	pattern = radical.ParsePattern(":id::archive")
+	require.Equal(t, []string{"id"}, pattern.Names)
+	require.NotEqual(t, radical.LiteralKey("1:archive"), pattern.Key)
+
+	values, ok = pattern.Match("1:archive")
+	require.True(t, ok)
+	require.Equal(t, []string{"1"}, values)
+
+	require.True(t, radical.IsPattern("items:id"))
+	require.True(t, radical.IsPattern("items:::id"))
+	require.False(t, radical.IsPattern("items::batchGet"))
+	require.Equal(t, "items:batchGet", radical.Unescape("items::batchGet"))
+	require.Equal(t, "items::batchGet", radical.Escape("items:batchGet"))
+
+	require.Panics(t, func() { radical.ParsePattern(":a:b") })
+	require.Panics(t, func() { radical.ParsePattern("v:") })
+	require.Panics(t, func() { radical.ParsePattern(":id<[a-z>.:ext") })
+}
+
+func TestParseParam(t *testing.T) {
+	name, constraint, matches := radical.ParseParam(":id")
+	require.Equal(t, "id", name)
+	require.Equal(t, "", constraint)
+	require.True(t, matches("anything"))
+	require.False(t, matches(""))
+
+	name, constraint, matches = radical.ParseParam(":id<int>")
+	require.Equal(t, "id", name)
+	require.Equal(t, "int", constraint)
+	require.True(t, matches("-12"))
+	require.False(t, matches("12a"))
+
+	name, constraint, matches = radical.ParseParam(":slug<[a-z]*>")
+	require.Equal(t, "slug", name)
+	require.Equal(t, "[a-z]*", constraint)
+	require.True(t, matches("abc"))
+	require.False(t, matches("abc1"))
+	require.False(t, matches(""))
+
+	require.Panics(t, func() {
+		radical.ParseParam(":bad<[a-z>")
+	})
+}
+
+func TestNode_ValueFold(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"Users", ":id", "posts"}, 1)
+	root.Add([]string{"users", ":id"}, 2)
+
+	ok, value := root.ValueFold([]string{"USERS", "Fox", "POSTS"})
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	ok, value = root.ValueFold([]string{"users", "Fox"})
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	ok, _ = root.Value([]string{"USERS", "Fox", "POSTS"})
+	require.False(t, ok)
+}
+
+// kind returns the priority of a route segment, lower values win.
+func kind(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		return 2
+	case strings.HasPrefix(segment, ":"):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// bruteForceMatch checks every route against the path and returns the index
+// of the highest priority matching route, or -1 if none match.
+func bruteForceMatch(routes [][]string, path []string) int {
+	best := -1
+
+	for i, route := range routes {
+		if !bruteForceMatches(route, path) {
+			continue
+		}
+
+		if best == -1 || higherPriority(route, routes[best]) {
+			best = i
+		}
+	}
+
+	return best
+}
+
+func bruteForceMatches(route []string, path []string) bool {
+	for i, segment := range route {
+		if kind(segment) == 2 {
+			return len(path) > i
+		}
+
+		if i >= len(path) {
+			return false
+		}
+
+		if kind(segment) == 1 {
+			if path[i] == "" {
+				return false
+			}
+			continue
+		}
+
+		if segment != path[i] {
+			return false
+		}
+	}
+
+	return len(route) == len(path)
+}
+
+func higherPriority(a []string, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if kind(a[i]) != kind(b[i]) {
+			return kind(a[i]) < kind(b[i])
+		}
+	}
+
+	return false
+}
+
+func TestNode_MatchesBruteForce(t *testing.T) {
+	segments := []string{"a", "b", "c", ":p", "*w"}
+	pathSegments := []string{"a", "b", "c", "d", ""}
+
+	for seed := int64(0); seed < 200; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		root := radical.New[int]()
+		routes := [][]string{}
+		shapes := map[string]bool{}
+
+		for i := 0; i < 12; i++ {
+			route := make([]string, rng.Intn(5))
+			for j := range route {
+				route[j] = segments[rng.Intn(len(segments))]
+				if kind(route[j]) == 2 {
+					route = route[:j+1]
+					break
+				}
+			}
+
+			shape := strings.Join(route, "/")
+			if shapes[shape] {
+				continue
+			}
+			shapes[shape] = true
+
+			root.Add(route, len(routes))
+			routes = append(routes, route)
+		}
+
+		for i := 0; i < 100; i++ {
+			path := make([]string, rng.Intn(6))
+			for j := range path {
+				path[j] = pathSegments[rng.Intn(len(pathSegments))]
+			}
+
+			want := bruteForceMatch(routes, path)
+			ok, got := root.Value(path)
+
+			msg := fmt.Sprintf("seed %d, routes %v, path %v", seed, routes, path)
+			if want == -1 {
+				require.False(t, ok, msg)
+				continue
+			}
+
+			require.True(t, ok, msg)
+			require.Equal(t, routes[want], routes[got], msg)
+		}
+	}
+}
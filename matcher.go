@@ -0,0 +1,249 @@
+package fernet
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// requestMatcher is a predicate a request must satisfy, in addition to the
+// method and path of a route, for the route to handle it.
+type requestMatcher struct {
+	// description describes the matcher in RouteInfo.
+	description string
+	// status is the status the request is rejected with when no route
+	// sharing the path matches it: 404 for headers, query params, and custom
+	// matchers, 415 for Content-Type, and 406 for Accept.
+	status int
+	fn     func(*http.Request) bool
+}
+
+// matcherStatuses is the order matchers are evaluated in, from the least to
+// the most specific failure.
+var matcherStatuses = [...]int{http.StatusNotFound, http.StatusUnsupportedMediaType, http.StatusNotAcceptable}
+
+// When adds a matcher that must return true for the route to handle a
+// request. Multiple routes can be registered with the same method and path
+// when they use matchers, and the first route registered whose matchers all
+// match the request handles it.
+func (r *Route[C]) When(fn func(req *http.Request) bool) *Route[C] {
+	return r.addMatcher(requestMatcher{description: "custom", status: http.StatusNotFound, fn: fn})
+}
+
+// Header adds a matcher requiring the request to have the given header. If
+// value isn't empty, the header must also have the given value.
+func (r *Route[C]) Header(name string, value string) *Route[C] {
+	description := "header " + http.CanonicalHeaderKey(name)
+	if value != "" {
+		description += "=" + value
+	}
+
+	return r.addMatcher(requestMatcher{
+		description: description,
+		status:      http.StatusNotFound,
+		fn: func(req *http.Request) bool {
+			values, ok := req.Header[http.CanonicalHeaderKey(name)]
+			if !ok {
+				return false
+			}
+
+			if value == "" {
+				return true
+			}
+
+			for _, v := range values {
+				if v == value {
+					return true
+				}
+			}
+
+			return false
+		},
+	})
+}
+
+// Query adds a matcher requiring the request to have the given query param.
+// If value isn't empty, the query param must also have the given value.
+func (r *Route[C]) Query(name string, value string) *Route[C] {
+	description := "query " + name
+	if value != "" {
+		description += "=" + value
+	}
+
+	return r.addMatcher(requestMatcher{
+		description: description,
+		status:      http.StatusNotFound,
+		fn: func(req *http.Request) bool {
+			values, ok := req.URL.Query()[name]
+			if !ok {
+				return false
+			}
+
+			if value == "" {
+				return true
+			}
+
+			for _, v := range values {
+				if v == value {
+					return true
+				}
+			}
+
+			return false
+		},
+	})
+}
+
+// ContentType adds a matcher requiring the Content-Type of the request to be
+// one of the given media types, ignoring parameters like charset. If no route
+// sharing the path matches, the request is rejected with a 415.
+func (r *Route[C]) ContentType(types ...string) *Route[C] {
+	return r.addMatcher(requestMatcher{
+		description: "content-type " + strings.Join(types, ", "),
+		status:      http.StatusUnsupportedMediaType,
+		fn: func(req *http.Request) bool {
+			mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+			if err != nil {
+				return false
+			}
+
+			for _, t := range types {
+				if strings.EqualFold(mediaType, t) {
+					return true
+				}
+			}
+
+			return false
+		},
+	})
+}
+
+// Accept adds a matcher requiring the Accept header of the request to accept
+// one of the given media types. Requests without an Accept header accept any
+// media type. If no route sharing the path matches, the request is rejected
+// with a 406.
+func (r *Route[C]) Accept(types ...string) *Route[C] {
+	return r.addMatcher(requestMatcher{
+		description: "accept " + strings.Join(types, ", "),
+		status:      http.StatusNotAcceptable,
+		fn: func(req *http.Request) bool {
+			accept := req.Header.Values("Accept")
+			if len(accept) == 0 {
+				return true
+			}
+
+			for _, t := range types {
+				if accepts(accept, t) {
+					return true
+				}
+			}
+
+			return false
+		},
+	})
+}
+
+// addMatcher adds a matcher to the route.
+func (r *Route[C]) addMatcher(matcher requestMatcher) *Route[C] {
+	r.ensureUncompiled(fmt.Sprintf("add a matcher to route %s %s", r.Method, r.Path))
+	r.matchers = append(r.matchers, matcher)
+
+	for _, alias := range r.aliases {
+		alias.addMatcher(matcher)
+	}
+
+	return r
+}
+
+// matchRequest returns 0 if the request satisfies every matcher of the route,
+// or the status of the first matcher it doesn't satisfy. Matchers are
+// evaluated in the order of matcherStatuses so that a request is only
+// rejected with a 415 or 406 when its headers and query params match.
+func (r *Route[C]) matchRequest(req *http.Request) int {
+	for _, status := range matcherStatuses {
+		for _, matcher := range r.matchers {
+			if matcher.status == status && !matcher.fn(req) {
+				return status
+			}
+		}
+	}
+
+	return 0
+}
+
+// selectCandidate returns the route that should handle the request out of
+// the routes sharing its method and path. Routes whose matchers don't match
+// the request, or whose API version is newer than the version requested, are
+// skipped. The route with the newest version is selected out of the remaining
+// routes, using registration order to break ties. If no route can be
+// selected, the status of the route that was closest to matching is returned
+// instead.
+func selectCandidate[T RequestContext](candidates []*Route[T], req *http.Request, reqParts []string) (*Route[T], int) {
+	var selected *Route[T]
+	status := http.StatusNotFound
+
+	for _, candidate := range candidates {
+		if candidateStatus := candidate.matchRequest(req); candidateStatus != 0 {
+			if matcherRank(candidateStatus) > matcherRank(status) {
+				status = candidateStatus
+			}
+
+			continue
+		}
+
+		if !candidate.matchesVersion(req, reqParts) {
+			continue
+		}
+
+		if selected == nil || candidate.version > selected.version {
+			selected = candidate
+		}
+	}
+
+	if selected == nil {
+		return nil, status
+	}
+
+	return selected, 0
+}
+
+// matcherRank returns the position of status in matcherStatuses.
+func matcherRank(status int) int {
+	for i, s := range matcherStatuses {
+		if s == status {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// accepts reports whether the media ranges of the Accept header values accept
+// mediaType. Ranges with a quality of 0 are excluded.
+func accepts(accept []string, mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			if q, ok := params["q"]; ok && strings.Trim(q, "0.") == "" {
+				continue
+			}
+
+			switch {
+			case mediaRange == "*/*", mediaRange == mediaType:
+				return true
+			case strings.HasSuffix(mediaRange, "/*") && strings.TrimSuffix(mediaRange, "/*") == typ:
+				return true
+			}
+		}
+	}
+
+	return false
+}
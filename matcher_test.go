@@ -0,0 +1,170 @@
+package fernet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Matchers(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	respond := func(body string) Handler[*RootRequestContext] {
+		return func(ctx context.Context, r *RootRequestContext) {
+			_, _ = r.Response().Write([]byte(body + " " + r.Param("id")))
+		}
+	}
+
+	router.Post("/events/:id", respond("json")).ContentType("application/json")
+	router.Post("/events/:event_id", respond("protobuf")).ContentType("application/protobuf", "application/x-protobuf")
+
+	router.Get("/reports/:id", respond("csv")).Accept("text/csv")
+	router.Get("/reports/:id", respond("html")).Accept("text/html")
+
+	router.Get("/search", respond("beta")).Header("X-Beta", "true")
+	router.Get("/search", respond("query")).Query("q", "")
+	router.Get("/search", respond("custom")).When(func(req *http.Request) bool {
+		return req.URL.Query().Get("page") == "2"
+	})
+
+	router.Get("/exports", respond("export")).Header("X-Token", "").Accept("application/json")
+
+	router.NotFound(func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusNotFound)
+		_, _ = r.Response().Write([]byte("not found"))
+	})
+
+	tests := map[string]struct {
+		method     string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantBody   string
+	}{
+		"json content type": {
+			method:     http.MethodPost,
+			path:       "/events/1",
+			header:     http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "json 1",
+		},
+		"protobuf content type": {
+			method:     http.MethodPost,
+			path:       "/events/2",
+			header:     http.Header{"Content-Type": {"application/x-protobuf"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "protobuf ",
+		},
+		"unsupported content type": {
+			method:     http.MethodPost,
+			path:       "/events/1",
+			header:     http.Header{"Content-Type": {"text/plain"}},
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		"missing content type": {
+			method:     http.MethodPost,
+			path:       "/events/1",
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		"accept exact": {
+			method:     http.MethodGet,
+			path:       "/reports/1",
+			header:     http.Header{"Accept": {"text/html"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "html 1",
+		},
+		"accept in order": {
+			method:     http.MethodGet,
+			path:       "/reports/1",
+			header:     http.Header{"Accept": {"text/*, application/json"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "csv 1",
+		},
+		"accept excluded by quality": {
+			method:     http.MethodGet,
+			path:       "/reports/1",
+			header:     http.Header{"Accept": {"text/csv;q=0, text/html;q=0.5"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "html 1",
+		},
+		"accept missing": {
+			method:     http.MethodGet,
+			path:       "/reports/1",
+			wantStatus: http.StatusOK,
+			wantBody:   "csv 1",
+		},
+		"not acceptable": {
+			method:     http.MethodGet,
+			path:       "/reports/1",
+			header:     http.Header{"Accept": {"application/json"}},
+			wantStatus: http.StatusNotAcceptable,
+		},
+		"header": {
+			method:     http.MethodGet,
+			path:       "/search?q=fox",
+			header:     http.Header{"X-Beta": {"true"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "beta ",
+		},
+		"header value mismatch falls through": {
+			method:     http.MethodGet,
+			path:       "/search?q=fox",
+			header:     http.Header{"X-Beta": {"false"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "query ",
+		},
+		"custom": {
+			method:     http.MethodGet,
+			path:       "/search?page=2",
+			wantStatus: http.StatusOK,
+			wantBody:   "custom ",
+		},
+		"no candidate matches": {
+			method:     http.MethodGet,
+			path:       "/search",
+			wantStatus: http.StatusNotFound,
+			wantBody:   "not found",
+		},
+		"header checked before accept": {
+			method:     http.MethodGet,
+			path:       "/exports",
+			header:     http.Header{"Accept": {"text/html"}},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "not found",
+		},
+		"accept checked after header": {
+			method:     http.MethodGet,
+			path:       "/exports",
+			header:     http.Header{"Accept": {"text/html"}, "X-Token": {"secret"}},
+			wantStatus: http.StatusNotAcceptable,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			for key, values := range tc.header {
+				req.Header[key] = values
+			}
+
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.wantStatus, res.Code)
+			require.Equal(t, tc.wantBody, res.Body.String())
+		})
+	}
+}
+
+func TestRouter_MatchersRoutes(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Post("/events", func(context.Context, *RootRequestContext) {}).
+		Header("x-source", "").
+		ContentType("application/json")
+
+	routes := router.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, []string{"header X-Source", "content-type application/json"}, routes[0].Matchers)
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"log/slog"
+	"net/http"
 
 	"github.com/blakewilliams/fernet"
 )
@@ -10,6 +11,11 @@ import (
 // ErrorHandler will catch panics in fernet applications and call the provided
 // handler so that an error response can be rendered. It automatically calls
 // `ResponseWriter.Clear` so partial responses aren't written to the client.
+//
+// If the response has already been committed, e.g. because it's streaming,
+// an error response can't be rendered. The handler isn't called and the
+// request is aborted by panicking with http.ErrAbortHandler so the client
+// doesn't mistake the partial response for a complete one.
 func ErrorHandler[T fernet.RequestContext](
 	log *slog.Logger,
 	handler func(ctx context.Context, rctx T, recovered any),
@@ -23,6 +29,10 @@ func ErrorHandler[T fernet.RequestContext](
 					log.Error("recovered in middleware")
 				}
 
+				if rctx.Response().Committed() {
+					panic(http.ErrAbortHandler)
+				}
+
 				rctx.Response().Clear()
 				handler(ctx, rctx, rec)
 			}
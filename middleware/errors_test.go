@@ -44,3 +44,31 @@ func TestErrors(t *testing.T) {
 	require.Equal(t, http.StatusOK, res.Code)
 	require.Equal(t, "all good!", res.Body.String())
 }
+
+func TestErrors_Committed(t *testing.T) {
+	router := fernet.New(func(r fernet.RequestContext) fernet.RequestContext {
+		return r
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var handled bool
+	router.Use(ErrorHandler(logger, func(ctx context.Context, r fernet.RequestContext, err any) {
+		handled = true
+	}))
+
+	router.Get("/stream", func(ctx context.Context, r fernet.RequestContext) {
+		r.Response().Stream()
+		_, _ = r.Response().Write([]byte("partial"))
+		panic("omg")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	res := httptest.NewRecorder()
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		router.ServeHTTP(res, req)
+	})
+	require.False(t, handled)
+	require.Equal(t, "partial", res.Body.String())
+}
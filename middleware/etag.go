@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/fernet"
+)
+
+type (
+	// ETagOption configures the ETag middleware.
+	ETagOption func(*etagOptions)
+
+	etagOptions struct {
+		weak bool
+	}
+)
+
+// WithWeakETag generates weak ETags like `W/"..."` instead of strong ETags.
+// Weak ETags only promise that responses are semantically equivalent, which
+// allows them to be used for responses that are compressed or otherwise
+// transformed before reaching the client.
+func WithWeakETag() ETagOption {
+	return func(o *etagOptions) {
+		o.weak = true
+	}
+}
+
+// ETag is a middleware that sets the ETag header of successful GET and HEAD
+// responses to a hash of their buffered body, unless the handler already set
+// one, and answers conditional requests using it.
+//
+// Requests whose If-None-Match header matches the ETag, or whose
+// If-Modified-Since header isn't older than the Last-Modified header set by
+// the handler, are answered with a 304 and an empty body. Requests whose
+// If-Match or If-Unmodified-Since preconditions fail are answered with a 412.
+// Streamed responses have already been sent, so they're left untouched.
+//
+// Preconditions on requests that change a resource have to be checked before
+// the change is made, so handlers check them using CheckPreconditions.
+func ETag[T fernet.RequestContext](opts ...ETagOption) func(context.Context, T, fernet.Handler[T]) {
+	o := etagOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx context.Context, rctx T, next fernet.Handler[T]) {
+		next(ctx, rctx)
+
+		req := rctx.Request()
+		res := rctx.Response()
+
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			return
+		}
+
+		if res.Committed() || res.Status() != http.StatusOK {
+			return
+		}
+
+		etag := res.Header().Get("ETag")
+		if etag == "" {
+			etag = computeETag(res.Body(), o.weak)
+			res.Header().Set("ETag", etag)
+		}
+
+		lastModified, _ := http.ParseTime(res.Header().Get("Last-Modified"))
+
+		switch status := evaluatePreconditions(req, etag, lastModified, true); status {
+		case http.StatusNotModified:
+			res.Clear()
+			res.Header().Del("Content-Length")
+			res.WriteHeader(status)
+		case http.StatusPreconditionFailed:
+			res.Clear()
+			res.WriteHeader(status)
+		}
+	}
+}
+
+// CheckPreconditions evaluates the If-Match, If-Unmodified-Since, and
+// If-None-Match headers of a request that changes a resource, using the
+// current ETag and modification time of the resource. An empty etag and zero
+// lastModified mean the resource doesn't exist yet.
+//
+// If a precondition fails, the response status is set to 412, or 304 for GET
+// and HEAD requests, and false is returned. The handler should return without
+// changing the resource.
+//
+//	router.Put("/posts/:id", func(ctx context.Context, r *AppContext) {
+//		post := findPost(r.Param("id"))
+//		if !middleware.CheckPreconditions(r, post.ETag(), post.UpdatedAt) {
+//			return
+//		}
+//
+//		// Update the post
+//	})
+func CheckPreconditions(rctx fernet.RequestContext, etag string, lastModified time.Time) bool {
+	exists := etag != "" || !lastModified.IsZero()
+
+	status := evaluatePreconditions(rctx.Request(), etag, lastModified, exists)
+	if status == 0 {
+		return true
+	}
+
+	rctx.Response().WriteHeader(status)
+	return false
+}
+
+// computeETag returns an ETag for body.
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// evaluatePreconditions evaluates the conditional headers of the request in
+// the order defined by RFC 9110 section 13.2.2, returning the status the
+// request should be answered with instead of the response, or 0 if every
+// precondition passes.
+func evaluatePreconditions(req *http.Request, etag string, lastModified time.Time, exists bool) int {
+	safe := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	if ifMatch := req.Header.Get("If-Match"); ifMatch != "" {
+		if !matchETag(ifMatch, etag, exists, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := parseConditionalTime(req.Header.Get("If-Unmodified-Since")); ok && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, exists, true) {
+			if safe {
+				return http.StatusNotModified
+			}
+
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := parseConditionalTime(req.Header.Get("If-Modified-Since")); ok && safe && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(since) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// matchETag reports whether the comma separated ETags of a conditional header
+// match etag. `*` matches any ETag as long as the resource exists. Weak
+// comparison ignores the weak indicator, while strong comparison never matches
+// weak ETags.
+func matchETag(header string, etag string, exists bool, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return exists
+	}
+
+	if etag == "" {
+		return false
+	}
+
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseConditionalTime parses the HTTP date of a conditional header.
+func parseConditionalTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := http.ParseTime(value)
+	return t, err == nil
+}
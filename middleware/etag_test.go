@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/fernet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	body := "hello world"
+	etag := computeETag([]byte(body), false)
+	lastModified := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := map[string]struct {
+		method       string
+		path         string
+		opts         []ETagOption
+		header       http.Header
+		status       int
+		expectedETag string
+		expectedBody string
+	}{
+		"sets etag": {
+			status:       http.StatusOK,
+			expectedETag: etag,
+			expectedBody: body,
+		},
+		"weak etag": {
+			opts:         []ETagOption{WithWeakETag()},
+			status:       http.StatusOK,
+			expectedETag: "W/" + etag,
+			expectedBody: body,
+		},
+		"if-none-match matches": {
+			header:       http.Header{"If-None-Match": {`"other", ` + etag}},
+			status:       http.StatusNotModified,
+			expectedETag: etag,
+		},
+		"if-none-match matches weakly": {
+			header:       http.Header{"If-None-Match": {"W/" + etag}},
+			status:       http.StatusNotModified,
+			expectedETag: etag,
+		},
+		"if-none-match wildcard": {
+			header:       http.Header{"If-None-Match": {"*"}},
+			status:       http.StatusNotModified,
+			expectedETag: etag,
+		},
+		"if-none-match doesn't match": {
+			header:       http.Header{"If-None-Match": {`"other"`}},
+			status:       http.StatusOK,
+			expectedETag: etag,
+			expectedBody: body,
+		},
+		"if-none-match head": {
+			method:       http.MethodHead,
+			header:       http.Header{"If-None-Match": {etag}},
+			status:       http.StatusNotModified,
+			expectedETag: etag,
+		},
+		"if-modified-since not modified": {
+			path:         "/modified",
+			header:       http.Header{"If-Modified-Since": {lastModified.Add(time.Hour).Format(http.TimeFormat)}},
+			status:       http.StatusNotModified,
+			expectedETag: etag,
+		},
+		"if-modified-since modified": {
+			path:         "/modified",
+			header:       http.Header{"If-Modified-Since": {lastModified.Add(-time.Hour).Format(http.TimeFormat)}},
+			status:       http.StatusOK,
+			expectedETag: etag,
+			expectedBody: body,
+		},
+		"if-none-match takes precedence over if-modified-since": {
+			path: "/modified",
+			header: http.Header{
+				"If-None-Match":     {`"other"`},
+				"If-Modified-Since": {lastModified.Format(http.TimeFormat)},
+			},
+			status:       http.StatusOK,
+			expectedETag: etag,
+			expectedBody: body,
+		},
+		"if-match fails": {
+			header:       http.Header{"If-Match": {`"other"`}},
+			status:       http.StatusPreconditionFailed,
+			expectedETag: etag,
+		},
+		"if-match weak etag fails": {
+			opts:         []ETagOption{WithWeakETag()},
+			header:       http.Header{"If-Match": {"W/" + etag}},
+			status:       http.StatusPreconditionFailed,
+			expectedETag: "W/" + etag,
+		},
+		"if-unmodified-since fails": {
+			path:         "/modified",
+			header:       http.Header{"If-Unmodified-Since": {lastModified.Add(-time.Hour).Format(http.TimeFormat)}},
+			status:       http.StatusPreconditionFailed,
+			expectedETag: etag,
+		},
+		"handler etag": {
+			path:         "/custom",
+			header:       http.Header{"If-None-Match": {`"custom"`}},
+			status:       http.StatusNotModified,
+			expectedETag: `"custom"`,
+		},
+		"error responses": {
+			path:   "/missing",
+			header: http.Header{"If-None-Match": {"*"}},
+			status: http.StatusNotFound,
+		},
+		"streamed responses": {
+			path:         "/stream",
+			header:       http.Header{"If-None-Match": {"*"}},
+			status:       http.StatusOK,
+			expectedBody: body,
+		},
+		"writes": {
+			method:       http.MethodPost,
+			header:       http.Header{"If-None-Match": {"*"}},
+			status:       http.StatusOK,
+			expectedBody: body,
+		},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			router := fernet.New(func(r fernet.RequestContext) fernet.RequestContext { return r })
+			router.Use(ETag[fernet.RequestContext](tc.opts...))
+
+			write := func(ctx context.Context, r fernet.RequestContext) {
+				_, _ = r.Response().Write([]byte(body))
+			}
+			router.Get("/", write)
+			router.Post("/", write)
+			router.Get("/modified", func(ctx context.Context, r fernet.RequestContext) {
+				r.Response().Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+				write(ctx, r)
+			})
+			router.Get("/custom", func(ctx context.Context, r fernet.RequestContext) {
+				r.Response().Header().Set("ETag", `"custom"`)
+				write(ctx, r)
+			})
+			router.Get("/missing", func(ctx context.Context, r fernet.RequestContext) {
+				r.Response().WriteHeader(http.StatusNotFound)
+			})
+			router.Get("/stream", write).Stream()
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			path := tc.path
+			if path == "" {
+				path = "/"
+			}
+
+			req := httptest.NewRequest(method, path, nil)
+			for name, values := range tc.header {
+				req.Header[name] = values
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.status, res.Code)
+			require.Equal(t, tc.expectedETag, res.Result().Header.Get("ETag"))
+			require.Equal(t, tc.expectedBody, res.Body.String())
+		})
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	etag := `"v1"`
+	lastModified := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := map[string]struct {
+		etag         string
+		lastModified time.Time
+		header       http.Header
+		allowed      bool
+	}{
+		"no preconditions": {
+			etag:    etag,
+			allowed: true,
+		},
+		"if-match matches": {
+			etag:    etag,
+			header:  http.Header{"If-Match": {`"v0", "v1"`}},
+			allowed: true,
+		},
+		"if-match doesn't match": {
+			etag:   etag,
+			header: http.Header{"If-Match": {`"v0"`}},
+		},
+		"if-match wildcard": {
+			etag:    etag,
+			header:  http.Header{"If-Match": {"*"}},
+			allowed: true,
+		},
+		"if-match wildcard missing resource": {
+			header: http.Header{"If-Match": {"*"}},
+		},
+		"if-unmodified-since unmodified": {
+			lastModified: lastModified,
+			header:       http.Header{"If-Unmodified-Since": {lastModified.Format(http.TimeFormat)}},
+			allowed:      true,
+		},
+		"if-unmodified-since modified": {
+			lastModified: lastModified,
+			header:       http.Header{"If-Unmodified-Since": {lastModified.Add(-time.Second).Format(http.TimeFormat)}},
+		},
+		"if-match takes precedence over if-unmodified-since": {
+			etag:         etag,
+			lastModified: lastModified,
+			header: http.Header{
+				"If-Match":            {etag},
+				"If-Unmodified-Since": {lastModified.Add(-time.Second).Format(http.TimeFormat)},
+			},
+			allowed: true,
+		},
+		"if-none-match wildcard creates": {
+			header:  http.Header{"If-None-Match": {"*"}},
+			allowed: true,
+		},
+		"if-none-match wildcard existing resource": {
+			etag:   etag,
+			header: http.Header{"If-None-Match": {"*"}},
+		},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			router := fernet.New(func(r fernet.RequestContext) fernet.RequestContext { return r })
+			router.Put("/posts/1", func(ctx context.Context, r fernet.RequestContext) {
+				if !CheckPreconditions(r, tc.etag, tc.lastModified) {
+					return
+				}
+
+				r.Response().WriteHeader(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodPut, "/posts/1", nil)
+			for name, values := range tc.header {
+				req.Header[name] = values
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			if tc.allowed {
+				require.Equal(t, http.StatusNoContent, res.Code)
+			} else {
+				require.Equal(t, http.StatusPreconditionFailed, res.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package fernet
+
+import (
+	"context"
+	"fmt"
+)
+
+type (
+	// middlewareStack is the middleware registered with a router or group.
+	// The order of the stack is resolved when it's used instead of when
+	// middleware is registered, so middleware can be registered at any time
+	// before the router is frozen and inserted relative to named middleware
+	// that hasn't been registered yet.
+	middlewareStack[T RequestContext] struct {
+		entries []*middlewareEntry[T]
+		// resolved is the cached order of entries, set once the router is
+		// frozen and the stack can no longer change.
+		resolved []*middlewareEntry[T]
+	}
+
+	// middlewareEntry is a middleware function registered with a stack.
+	middlewareEntry[T RequestContext] struct {
+		// name is the name the middleware was registered with, if any.
+		name string
+		fn   func(context.Context, T, Handler[T])
+		// target is the name of the middleware this entry is inserted
+		// relative to, and after reports whether it's inserted after target
+		// instead of before it.
+		target string
+		after  bool
+	}
+)
+
+// use appends fns to the stack.
+func (s *middlewareStack[T]) use(fns ...func(context.Context, T, Handler[T])) {
+	for _, fn := range fns {
+		s.entries = append(s.entries, &middlewareEntry[T]{fn: fn})
+	}
+}
+
+// useNamed appends fn to the stack with the given name. It panics if the name
+// is already used by other middleware in the stack.
+func (s *middlewareStack[T]) useNamed(name string, fn func(context.Context, T, Handler[T])) {
+	for _, entry := range s.entries {
+		if entry.name == name {
+			panic(fmt.Sprintf("middleware named %q is already registered", name))
+		}
+	}
+
+	s.entries = append(s.entries, &middlewareEntry[T]{name: name, fn: fn})
+}
+
+// useRelative adds fns to the stack immediately before or after the
+// middleware named target.
+func (s *middlewareStack[T]) useRelative(target string, after bool, fns ...func(context.Context, T, Handler[T])) {
+	for _, fn := range fns {
+		s.entries = append(s.entries, &middlewareEntry[T]{fn: fn, target: target, after: after})
+	}
+}
+
+// freeze caches the resolved order of the stack.
+func (s *middlewareStack[T]) freeze() {
+	s.resolved = s.resolve()
+}
+
+// resolve returns the entries of the stack in the order they run. Middleware
+// registered with Use and UseNamed runs in the order it was registered.
+// Middleware registered relative to named middleware is then inserted in the
+// order it was registered, so multiple middleware inserted before or after
+// the same middleware keep their registration order.
+//
+// resolve panics if middleware is inserted relative to a name that isn't
+// registered with the stack.
+func (s *middlewareStack[T]) resolve() []*middlewareEntry[T] {
+	if s.resolved != nil {
+		return s.resolved
+	}
+
+	resolved := make([]*middlewareEntry[T], 0, len(s.entries))
+	pending := make([]*middlewareEntry[T], 0)
+
+	for _, entry := range s.entries {
+		if entry.target == "" {
+			resolved = append(resolved, entry)
+		} else {
+			pending = append(pending, entry)
+		}
+	}
+
+	insertedAfter := make(map[string]int)
+
+	// Relative middleware is never named, so it can only target middleware
+	// registered with UseNamed, which is already in resolved.
+	for _, entry := range pending {
+		i := indexOfMiddleware(resolved, entry.target)
+		if i == -1 {
+			method := "UseBefore"
+			if entry.after {
+				method = "UseAfter"
+			}
+
+			panic(fmt.Sprintf("middleware named %q passed to %s is not registered", entry.target, method))
+		}
+
+		if entry.after {
+			i += 1 + insertedAfter[entry.target]
+			insertedAfter[entry.target]++
+		}
+
+		resolved = append(resolved[:i], append([]*middlewareEntry[T]{entry}, resolved[i:]...)...)
+	}
+
+	return resolved
+}
+
+// displayName returns the name of the middleware, falling back to the name of
+// its function.
+func (e *middlewareEntry[T]) displayName() string {
+	if e.name != "" {
+		return e.name
+	}
+
+	return funcName(e.fn)
+}
+
+func indexOfMiddleware[T RequestContext](entries []*middlewareEntry[T], name string) int {
+	for i, entry := range entries {
+		if entry.name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// chain returns handler wrapped by the given middleware, with the first
+// middleware running first.
+func chain[T RequestContext](entries []*middlewareEntry[T], handler Handler[T]) Handler[T] {
+	for i := len(entries) - 1; i >= 0; i-- {
+		next := handler
+		m := entries[i].fn
+
+		handler = func(ctx context.Context, rctx T) {
+			m(ctx, rctx, next)
+		}
+	}
+
+	return handler
+}
@@ -0,0 +1,28 @@
+package fernet
+
+import (
+	"context"
+	"net/http"
+)
+
+// anyMethod is used in place of an HTTP method to register routes that match
+// every method. Routes registered for a specific method take priority. Like the
+// fallback methods it contains a slash, so it can't conflict with a real method
+// such as one registered using Match("ANY", ...).
+const anyMethod = "fernet/any"
+
+// mount registers handler with the registerable for every method at prefix
+// and all paths below it. The prefix is stripped from the request URL before
+// the handler is called.
+func mount[T RequestContext](r Registerable[T], prefix string, handler http.Handler) {
+	fn := func(ctx context.Context, rctx T) {
+		req := rctx.Request().Clone(ctx)
+		req.URL.Path = "/" + rctx.Param("")
+		req.URL.RawPath = ""
+
+		handler.ServeHTTP(rctx.Response(), req)
+	}
+
+	r.RawMatch(anyMethod, prefix, fn)
+	r.RawMatch(anyMethod, joinURL(prefix, "/*"), fn)
+}
@@ -0,0 +1,76 @@
+package fernet
+
+// Option configures the behavior of a Router. Options are passed to New.
+type Option func(*options)
+
+// TrailingSlashPolicy determines how the router handles requests that don't
+// match a route because of a trailing slash.
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashRedirect redirects requests to the path with the trailing
+	// slash added or removed when that path matches a route.
+	TrailingSlashRedirect TrailingSlashPolicy = iota
+	// TrailingSlashStrict requires the trailing slash of a request to match
+	// the registered route exactly.
+	TrailingSlashStrict
+)
+
+type options struct {
+	implicitHead            bool
+	implicitOptions         bool
+	cleanPathRedirect       bool
+	trailingSlash           TrailingSlashPolicy
+	caseInsensitiveRedirect bool
+}
+
+func defaultOptions() options {
+	return options{
+		implicitHead:      true,
+		implicitOptions:   true,
+		cleanPathRedirect: true,
+		trailingSlash:     TrailingSlashRedirect,
+	}
+}
+
+// WithoutImplicitHead disables running GET handlers for HEAD requests when no
+// HEAD route has been registered for the requested path.
+func WithoutImplicitHead() Option {
+	return func(o *options) {
+		o.implicitHead = false
+	}
+}
+
+// WithoutImplicitOptions disables automatically responding to OPTIONS
+// requests with the methods registered for the requested path when no OPTIONS
+// route has been registered for it.
+func WithoutImplicitOptions() Option {
+	return func(o *options) {
+		o.implicitOptions = false
+	}
+}
+
+// WithoutCleanPathRedirect disables redirecting requests with paths containing
+// repeated slashes or `.` and `..` elements to the cleaned path.
+func WithoutCleanPathRedirect() Option {
+	return func(o *options) {
+		o.cleanPathRedirect = false
+	}
+}
+
+// WithTrailingSlash sets the policy used for requests that only fail to match
+// a route because of a trailing slash. Defaults to TrailingSlashRedirect.
+func WithTrailingSlash(policy TrailingSlashPolicy) Option {
+	return func(o *options) {
+		o.trailingSlash = policy
+	}
+}
+
+// WithCaseInsensitiveRedirect enables redirecting requests that only match a
+// route when compared case-insensitively to the path using the casing of the
+// registered route.
+func WithCaseInsensitiveRedirect() Option {
+	return func(o *options) {
+		o.caseInsensitiveRedirect = true
+	}
+}
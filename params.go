@@ -0,0 +1,56 @@
+package fernet
+
+// Param is a single param captured from the request by the matched route or
+// host pattern.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params are the params captured from the request, in the order they appear
+// in the host pattern and route path. Params are stored in a slice that is
+// reused across requests, so they must not be retained after the request has
+// been handled.
+type Params []Param
+
+// Get returns the value of the param with the given name and whether it was
+// captured.
+func (p Params) Get(name string) (string, bool) {
+	for i := range p {
+		if p[i].Key == name {
+			return p[i].Value, true
+		}
+	}
+
+	return "", false
+}
+
+// ByName returns the value of the param with the given name, or an empty
+// string if it wasn't captured.
+func (p Params) ByName(name string) string {
+	value, _ := p.Get(name)
+	return value
+}
+
+// Map returns the params as a newly allocated map.
+func (p Params) Map() map[string]string {
+	params := make(map[string]string, len(p))
+	for _, param := range p {
+		params[param.Key] = param.Value
+	}
+
+	return params
+}
+
+// set sets the value of the param with the given name, adding it if it wasn't
+// captured yet.
+func (p Params) set(name string, value string) Params {
+	for i := range p {
+		if p[i].Key == name {
+			p[i].Value = value
+			return p
+		}
+	}
+
+	return append(p, Param{Key: name, Value: value})
+}
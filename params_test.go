@@ -0,0 +1,26 @@
+package fernet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams(t *testing.T) {
+	params := Params{{Key: "id", Value: "1"}, {Key: "format", Value: "json"}}
+
+	value, ok := params.Get("id")
+	require.True(t, ok)
+	require.Equal(t, "1", value)
+
+	_, ok = params.Get("missing")
+	require.False(t, ok)
+
+	require.Equal(t, "json", params.ByName("format"))
+	require.Equal(t, "", params.ByName("missing"))
+	require.Equal(t, map[string]string{"id": "1", "format": "json"}, params.Map())
+
+	params = params.set("id", "2")
+	params = params.set("page", "3")
+	require.Equal(t, Params{{Key: "id", Value: "2"}, {Key: "format", Value: "json"}, {Key: "page", Value: "3"}}, params)
+}
@@ -1,7 +1,11 @@
 package fernet
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 )
 
 // RequestContext is an interface that exposes the http.Request,
@@ -13,30 +17,104 @@ type RequestContext interface {
 	// Writer returns a fernet.Response
 	Response() Response
 	// Params returns the parameters extracted from the URL path based on the
-	// matched route.
+	// matched route. A new map is allocated on each call, so Param should be
+	// preferred when reading a single param.
 	Params() map[string]string
+	// Param returns the value of the named param, or an empty string if it
+	// was not captured.
+	Param(name string) string
+	// ParamInt returns the named param parsed as an int. An error is returned
+	// if the param does not exist or is not a valid integer.
+	ParamInt(name string) (int, error)
 	// MatchedPath returns the path that was matched by the router.
 	MatchedPath() string
+	// Metadata returns the metadata value registered with the matched route
+	// or the groups it was registered through.
+	Metadata(key string) (any, bool)
 }
 
+// ErrParamNotFound is returned when a param that was not captured by the
+// matched route is requested.
+var ErrParamNotFound = errors.New("param not found")
+
 // BasicRequestContext is a basic implementation of RequestContext. It can be embedded in
 // other types to provide a default implementation of the RequestContext interface.
+//
+// The router recycles RootRequestContexts, their params, and their response
+// buffers once the response has been flushed, so they must not be retained
+// or used after the handler returns.
 type RootRequestContext struct {
 	req         *http.Request
-	res         Response
-	params      map[string]string
+	writer      responseWriter
+	params      Params
 	matchedPath string
+	metadata    map[string]any
+	// parts is a reusable buffer for the segments of the request path.
+	parts []string
 }
 
 var _ RequestContext = (*RootRequestContext)(nil)
 
+// maxPooledBodySize is the largest response buffer that is kept when a
+// RootRequestContext is returned to the pool, so that a single large response
+// doesn't keep its buffer alive indefinitely.
+const maxPooledBodySize = 64 << 10
+
+var requestContextPool = sync.Pool{
+	New: func() any { return &RootRequestContext{} },
+}
+
 func NewRequestContext(req *http.Request, res http.ResponseWriter, matchedPath string, params map[string]string) *RootRequestContext {
-	return &RootRequestContext{
-		req:         req,
-		res:         newResponseWriter(res),
-		matchedPath: matchedPath,
-		params:      params,
+	rctx := &RootRequestContext{}
+	rctx.reset(req, res)
+	rctx.matchedPath = matchedPath
+
+	for key, value := range params {
+		rctx.params = append(rctx.params, Param{Key: key, Value: value})
 	}
+
+	return rctx
+}
+
+// acquireRequestContext returns a RootRequestContext for the request from the
+// pool.
+func acquireRequestContext(req *http.Request, rw http.ResponseWriter) *RootRequestContext {
+	rctx := requestContextPool.Get().(*RootRequestContext)
+	rctx.reset(req, rw)
+
+	return rctx
+}
+
+// releaseRequestContext returns rctx to the pool once its response has been
+// flushed. References to the request and response are cleared so they can be
+// garbage collected.
+func releaseRequestContext(rctx *RootRequestContext) {
+	body := rctx.writer.body[:0]
+	if cap(body) > maxPooledBodySize {
+		body = nil
+	}
+
+	*rctx = RootRequestContext{
+		writer: responseWriter{body: body},
+		params: rctx.params[:0],
+		parts:  rctx.parts[:0],
+	}
+
+	requestContextPool.Put(rctx)
+}
+
+// reset prepares the request context to handle req, reusing its buffers.
+func (r *RootRequestContext) reset(req *http.Request, rw http.ResponseWriter) {
+	r.req = req
+	r.writer = responseWriter{
+		status:      http.StatusOK,
+		body:        r.writer.body[:0],
+		rw:          rw,
+		discardBody: req.Method == http.MethodHead,
+	}
+	r.params = r.params[:0]
+	r.matchedPath = ""
+	r.metadata = nil
 }
 
 func (r *RootRequestContext) Request() *http.Request {
@@ -44,13 +122,53 @@ func (r *RootRequestContext) Request() *http.Request {
 }
 
 func (r *RootRequestContext) Response() Response {
-	return r.res
+	return &r.writer
 }
 
 func (r *RootRequestContext) Params() map[string]string {
-	return r.params
+	return r.params.Map()
+}
+
+func (r *RootRequestContext) Param(name string) string {
+	return r.params.ByName(name)
+}
+
+func (r *RootRequestContext) ParamInt(name string) (int, error) {
+	value, ok := r.params.Get(name)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrParamNotFound, name)
+	}
+
+	return strconv.Atoi(value)
 }
 
 func (r *RootRequestContext) MatchedPath() string {
 	return r.matchedPath
 }
+
+func (r *RootRequestContext) Metadata(key string) (any, bool) {
+	value, ok := r.metadata[key]
+	return value, ok
+}
+
+// Meta returns the metadata value of the matched route for key as a V. False
+// is returned if the value does not exist or is not a V.
+//
+//	if role, ok := fernet.Meta[string](rctx, "role"); ok {
+//		// ...
+//	}
+func Meta[V any](rctx RequestContext, key string) (V, bool) {
+	var zero V
+
+	value, ok := rctx.Metadata(key)
+	if !ok {
+		return zero, false
+	}
+
+	typed, ok := value.(V)
+	if !ok {
+		return zero, false
+	}
+
+	return typed, true
+}
@@ -1,26 +1,53 @@
 package fernet
 
 import (
+	"bufio"
 	"errors"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Response is an interface that adds additional behavior to
 // http.ResponseWriter. It exposes the status written, allows the buffered body
 // to be reset via `Clear`, and can Flush the response.
+//
+// Responses are buffered until the handler returns by default. Calling
+// Stream switches the response to streaming mode, where the status and
+// headers are sent to the client by the first call to WriteHeader or Write
+// and writes go straight to the client instead of being buffered.
 type Response interface {
 	// Status returns the status to be written to the client
 	Status() int
-	// Flush writes the response to the client
+	// Flush writes the response to the client. For streamed responses it
+	// sends the headers if they haven't been sent yet and flushes any data
+	// buffered by the underlying writer, and can be called repeatedly.
 	Flush() (int, error)
-	// Clear resets the buffered response body
+	// Clear resets the buffered response body. It has no effect once the
+	// response has been committed.
 	Clear()
+	// Body returns the buffered response body that hasn't been sent to the
+	// client yet. The returned slice is only valid until the response is
+	// written to or cleared.
+	Body() []byte
+	// Stream switches the response to streaming mode. Anything buffered
+	// before Stream is called is sent when the response is committed.
+	Stream()
+	// Committed reports whether the status and headers have been sent to
+	// the client, after which they can no longer be changed.
+	Committed() bool
 	http.ResponseWriter
 }
 
 // ErrAlreadyFlushed is returned when the response would have been written twice.
 var ErrAlreadyFlushed error = errors.New("response has already been flushed")
 
+// ErrHijackAfterWrite is returned when hijacking a response after its body has
+// been written to.
+var ErrHijackAfterWrite error = errors.New("response cannot be hijacked after its body has been written")
+
 // responseWriter implements the http.responseWriter interface and exposes
 // additional information about the response like the status code and number of
 // bytes written.
@@ -29,31 +56,121 @@ type responseWriter struct {
 	body    []byte
 	rw      http.ResponseWriter
 	flushed bool
+	// discardBody is set for HEAD requests so that the buffered body is not
+	// written to the client while its Content-Length is still reported.
+	discardBody bool
+	// streaming is set by Stream, and committed is set once the status and
+	// headers have been written to rw.
+	streaming bool
+	committed bool
+	// hijacked is set once the connection has been taken over by Hijack.
+	hijacked bool
 }
 
 var _ http.ResponseWriter = (*responseWriter)(nil)
+var _ io.StringWriter = (*responseWriter)(nil)
+var _ http.Hijacker = (*responseWriter)(nil)
 
-func newResponseWriter(rw http.ResponseWriter) *responseWriter {
-	return &responseWriter{
-		status: 200,
-		body:   []byte{},
-		rw:     rw,
+// WriteHeader writes the status code of the response. Streamed responses are
+// committed immediately, except for informational 1xx statuses which are sent
+// to the client as is.
+func (r *responseWriter) WriteHeader(status int) {
+	if r.committed {
+		return
+	}
+
+	if r.streaming && status >= 100 && status < 200 {
+		r.rw.WriteHeader(status)
+		return
 	}
-}
 
-// WriteHeader writes the status code of the response.
-func (r *responseWriter) WriteHeader(status int) {
 	r.status = status
+
+	if r.streaming {
+		_ = r.commit()
+	}
 }
 
 // Write implements the http.ResponseWriter interface and buffers the bytes to
-// be written.
+// be written. Streamed responses are written to the client immediately.
 func (r *responseWriter) Write(b []byte) (int, error) {
+	if r.hijacked {
+		return 0, http.ErrHijacked
+	}
+
+	if r.streaming {
+		if err := r.commit(); err != nil {
+			return 0, err
+		}
+
+		if r.discardBody {
+			return len(b), nil
+		}
+
+		return r.rw.Write(b)
+	}
+
 	r.body = append(r.body, b...)
 
 	return len(b), nil
 }
 
+// WriteString implements io.StringWriter and buffers the string to be written
+// without converting it to a byte slice first.
+func (r *responseWriter) WriteString(s string) (int, error) {
+	if r.hijacked {
+		return 0, http.ErrHijacked
+	}
+
+	if r.streaming {
+		if err := r.commit(); err != nil {
+			return 0, err
+		}
+
+		if r.discardBody {
+			return len(s), nil
+		}
+
+		return io.WriteString(r.rw, s)
+	}
+
+	r.body = append(r.body, s...)
+
+	return len(s), nil
+}
+
+// Stream switches the response to streaming mode.
+func (r *responseWriter) Stream() {
+	r.streaming = true
+}
+
+// Committed reports whether the status and headers have been written to the
+// client.
+func (r *responseWriter) Committed() bool {
+	return r.committed
+}
+
+// commit writes the status, headers, and anything buffered before the
+// response started streaming to the client, if they haven't been already.
+func (r *responseWriter) commit() error {
+	if r.committed {
+		return nil
+	}
+
+	r.committed = true
+	r.rw.WriteHeader(r.status)
+
+	body := r.body
+	r.body = r.body[:0]
+
+	if len(body) > 0 && !r.discardBody {
+		_, err := r.rw.Write(body)
+		return err
+	}
+
+	return nil
+}
+
 // Header represents the header map of the response.
 func (r *responseWriter) Header() http.Header {
 	return r.rw.Header()
@@ -65,17 +182,124 @@ func (r *responseWriter) Status() int {
 }
 
 // Flush writes the buffered bytes to the underlying http.ResponseWriter.
+// Streamed responses have already been written, so Flush only commits them if
+// needed and flushes the underlying http.ResponseWriter.
 func (r *responseWriter) Flush() (int, error) {
+	if r.hijacked {
+		return 0, nil
+	}
+
+	if r.streaming {
+		if err := r.commit(); err != nil {
+			return 0, err
+		}
+
+		// The response controller also flushes writers that don't implement
+		// http.Flusher, like the response of a router this one is mounted in.
+		if err := http.NewResponseController(r.rw).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return 0, err
+		}
+
+		return 0, nil
+	}
+
 	if r.flushed {
 		return 0, ErrAlreadyFlushed
 	}
 
 	r.flushed = true
+	r.committed = true
+
+	if r.discardBody {
+		if len(r.body) > 0 && r.rw.Header().Get("Content-Length") == "" {
+			r.rw.Header().Set("Content-Length", strconv.Itoa(len(r.body)))
+		}
+
+		r.rw.WriteHeader(r.status)
+		return 0, nil
+	}
+
 	r.rw.WriteHeader(r.status)
 	return r.rw.Write(r.body)
 }
 
-// Clear resets the body that would be written to the client
+// Clear resets the body that would be written to the client. Committed
+// responses can't be cleared.
 func (r *responseWriter) Clear() {
-	r.body = []byte{}
+	if r.committed {
+		return
+	}
+
+	r.body = r.body[:0]
+}
+
+// Body returns the buffered body that hasn't been written to the client.
+func (r *responseWriter) Body() []byte {
+	return r.body
+}
+
+// FlushError sends the response written so far to the client and is used by
+// http.ResponseController to flush the response. Buffered responses are
+// committed and switched to streaming mode, since anything written after the
+// flush has to be sent after what was already sent.
+func (r *responseWriter) FlushError() error {
+	if r.hijacked {
+		return http.ErrHijacked
+	}
+
+	r.streaming = true
+	if err := r.commit(); err != nil {
+		return err
+	}
+
+	return http.NewResponseController(r.rw).Flush()
+}
+
+// Hijack implements http.Hijacker, letting the handler take over the
+// connection. Responses can only be hijacked before anything has been
+// written to their body, after which ErrHijackAfterWrite is returned. Once
+// hijacked, the response is considered committed and isn't written to the
+// connection when the handler returns.
+func (r *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if r.hijacked {
+		return nil, nil, http.ErrHijacked
+	}
+
+	if r.committed || len(r.body) > 0 {
+		return nil, nil, ErrHijackAfterWrite
+	}
+
+	conn, rw, err := http.NewResponseController(r.rw).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.hijacked = true
+	r.committed = true
+
+	return conn, rw, nil
+}
+
+// SetReadDeadline sets the deadline for reading the request body using the
+// underlying http.ResponseWriter.
+func (r *responseWriter) SetReadDeadline(deadline time.Time) error {
+	return http.NewResponseController(r.rw).SetReadDeadline(deadline)
+}
+
+// SetWriteDeadline sets the deadline for writing the response using the
+// underlying http.ResponseWriter.
+func (r *responseWriter) SetWriteDeadline(deadline time.Time) error {
+	return http.NewResponseController(r.rw).SetWriteDeadline(deadline)
+}
+
+// EnableFullDuplex allows the request body to be read while the response is
+// being written, using the underlying http.ResponseWriter.
+func (r *responseWriter) EnableFullDuplex() error {
+	return http.NewResponseController(r.rw).EnableFullDuplex()
+}
+
+// Unwrap returns the underlying http.ResponseWriter. Writing to it directly
+// bypasses the response buffer.
+func (r *responseWriter) Unwrap() http.ResponseWriter {
+	return r.rw
 }
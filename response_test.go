@@ -0,0 +1,316 @@
+package fernet
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponse_Stream(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	router.Get("/stream", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("buffered "))
+		r.Response().Header().Set("X-Stream", "true")
+		r.Response().Stream()
+		require.False(t, r.Response().Committed())
+
+		r.Response().WriteHeader(http.StatusCreated)
+		require.True(t, r.Response().Committed())
+		require.Equal(t, http.StatusCreated, r.Response().Status())
+
+		// Headers and statuses can't change once the response is committed.
+		r.Response().WriteHeader(http.StatusInternalServerError)
+		r.Response().Header().Set("X-Late", "true")
+		require.Equal(t, http.StatusCreated, r.Response().Status())
+
+		_, _ = r.Response().Write([]byte("streamed"))
+		r.Response().Clear()
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	// Result returns the headers as they were when the response was
+	// committed.
+	result := res.Result()
+	require.Equal(t, http.StatusCreated, result.StatusCode)
+	require.Equal(t, "true", result.Header.Get("X-Stream"))
+	require.Empty(t, result.Header.Get("X-Late"))
+	require.Equal(t, "buffered streamed", res.Body.String())
+	require.True(t, res.Flushed)
+}
+
+func TestResponse_StreamRoute(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	var committed bool
+	router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		next(ctx, r)
+		committed = r.Response().Committed()
+	})
+	router.Get("/stream", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("streamed"))
+	}).Stream()
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "streamed", res.Body.String())
+	require.True(t, committed)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodHead, "/stream", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Empty(t, res.Body.String())
+}
+
+func TestResponse_StreamMounted(t *testing.T) {
+	res := httptest.NewRecorder()
+
+	inner := New(WithBasicRequestContext)
+	inner.Get("/events", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("event"))
+		_, err := r.Response().Flush()
+		require.NoError(t, err)
+
+		require.True(t, res.Flushed)
+		require.Equal(t, "event", res.Body.String())
+	}).Stream()
+
+	router := New(WithBasicRequestContext)
+	router.Mount("/stream", inner)
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/stream/events", nil))
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "event", res.Body.String())
+}
+
+func TestResponse_StreamWithoutWrites(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/stream", func(ctx context.Context, r *RootRequestContext) {
+		r.Response().Stream()
+		r.Response().Header().Set("X-Empty", "true")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "true", res.Header().Get("X-Empty"))
+}
+
+func TestResponse_StreamServer(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	release := make(chan struct{})
+	router.Get("/stream", func(ctx context.Context, r *RootRequestContext) {
+		r.Response().Stream()
+
+		_, _ = r.Response().Write([]byte("first\n"))
+		_, _ = r.Response().Flush()
+
+		<-release
+		_, _ = r.Response().Write([]byte("second\n"))
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/stream")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Empty(t, res.Header.Get("Content-Length"))
+
+	// The first line is received while the handler is still running.
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "first\n", line)
+
+	close(release)
+
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "second\n", line)
+}
+
+func TestResponse_ResponseControllerFlush(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	// Handlers run on server goroutines, so results are sent back to the test
+	// instead of being checked there.
+	type flushResult struct {
+		err       error
+		committed bool
+		status    int
+	}
+	results := make(chan flushResult, 1)
+	release := make(chan struct{})
+
+	router.Get("/flush", func(ctx context.Context, r *RootRequestContext) {
+		r.Response().WriteHeader(http.StatusAccepted)
+		_, _ = r.Response().Write([]byte("first\n"))
+
+		err := http.NewResponseController(r.Response()).Flush()
+		results <- flushResult{err: err, committed: r.Response().Committed(), status: r.Response().Status()}
+
+		<-release
+		_, _ = r.Response().Write([]byte("second\n"))
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/flush")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "first\n", line)
+
+	result := <-results
+	require.NoError(t, result.err)
+	require.True(t, result.committed)
+	require.Equal(t, http.StatusAccepted, result.status)
+
+	close(release)
+
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "second\n", line)
+}
+
+func TestResponse_ResponseControllerHijack(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	type hijackResult struct {
+		err       error
+		committed bool
+		writeErr  error
+	}
+	results := make(chan hijackResult, 2)
+
+	router.Get("/hijack", func(ctx context.Context, r *RootRequestContext) {
+		conn, rw, err := http.NewResponseController(r.Response()).Hijack()
+		if err != nil {
+			results <- hijackResult{err: err}
+			return
+		}
+		defer conn.Close()
+
+		_, writeErr := r.Response().Write([]byte("ignored"))
+		results <- hijackResult{committed: r.Response().Committed(), writeErr: writeErr}
+
+		_, _ = rw.WriteString("HTTP/1.1 418 I'm a teapot\r\nContent-Length: 6\r\nConnection: close\r\n\r\nteapot")
+		_ = rw.Flush()
+	})
+
+	router.Get("/hijack-after-write", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("written"))
+
+		_, _, err := http.NewResponseController(r.Response()).Hijack()
+		results <- hijackResult{err: err}
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/hijack")
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTeapot, res.StatusCode)
+	require.Equal(t, "teapot", string(body))
+
+	result := <-results
+	require.NoError(t, result.err)
+	require.True(t, result.committed)
+	require.ErrorIs(t, result.writeErr, http.ErrHijacked)
+
+	res, err = http.Get(server.URL + "/hijack-after-write")
+	require.NoError(t, err)
+	body, err = io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "written", string(body))
+	require.ErrorIs(t, (<-results).err, ErrHijackAfterWrite)
+}
+
+func TestResponse_ResponseControllerDeadlines(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	errs := make(chan error, 3)
+	router.Get("/deadlines", func(ctx context.Context, r *RootRequestContext) {
+		rc := http.NewResponseController(r.Response())
+		errs <- rc.SetReadDeadline(time.Now().Add(time.Minute))
+		errs <- rc.SetWriteDeadline(time.Now().Add(time.Minute))
+		errs <- rc.EnableFullDuplex()
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/deadlines")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	// Recorders don't support deadlines, which is reported instead of being
+	// ignored.
+	router = New(WithBasicRequestContext)
+	router.Get("/deadlines", func(ctx context.Context, r *RootRequestContext) {
+		rc := http.NewResponseController(r.Response())
+		require.ErrorIs(t, rc.SetWriteDeadline(time.Now()), http.ErrNotSupported)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/deadlines", nil))
+}
+
+func TestResponse_Unwrap(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	res := httptest.NewRecorder()
+
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {
+		unwrapper, ok := r.Response().(interface{ Unwrap() http.ResponseWriter })
+		require.True(t, ok)
+		require.Same(t, res, unwrapper.Unwrap())
+	})
+
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestResponse_Body(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {
+		require.Empty(t, r.Response().Body())
+
+		_, _ = r.Response().Write([]byte("hello"))
+		require.Equal(t, "hello", string(r.Response().Body()))
+
+		r.Response().Stream()
+		_, _ = r.Response().Write([]byte(" world"))
+		require.Empty(t, r.Response().Body())
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, "hello world", res.Body.String())
+}
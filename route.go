@@ -1,60 +1,518 @@
 package fernet
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
+	"sync/atomic"
+
+	"github.com/blakewilliams/fernet/internal/radical"
 )
 
-type route[T RequestContext] struct {
+// Route represents a route registered with a Router. It is returned when
+// registering a route so that it can be further configured, e.g. named.
+type Route[T RequestContext] struct {
 	Method  string
 	Path    string
+	name    string
 	parts   []string
+	params  []*radical.Pattern
 	handler Handler[T]
+	// stacks are the middleware stacks of the router and groups the route was
+	// registered through, ordered from the router to the innermost group.
+	// They're referenced instead of copied so that middleware registered on
+	// a group after the route is defined is still applied.
+	stacks []*middlewareStack[T]
+	// routeMiddleware is the middleware registered for only this route. It
+	// runs after the middleware stacks and before the handler.
+	routeMiddleware []func(context.Context, T, Handler[T])
+	// groupMetadata is the metadata of the groups the route was registered
+	// through, ordered from the outermost to the innermost group.
+	groupMetadata []map[string]any
+	// routeMetadata is the metadata registered for only this route.
+	routeMetadata map[string]any
+	// host is the host pattern the route is restricted to, if any, and
+	// hostPattern is its parsed form.
+	host        string
+	hostPattern *hostPattern
+	// disabled is set when the route is removed from the router by Disable.
+	disabled atomic.Bool
+	// matchers are the predicates, other than the method and path, that a
+	// request must satisfy for the route to handle it.
+	matchers []requestMatcher
+	// source and line are the location the route was registered from.
+	source string
+	line   int
+	// ensureMutable panics if the router the route belongs to is frozen and
+	// not being updated.
+	ensureMutable func(action string)
+	// compiled and compiledMetadata are the handler wrapped by the route's
+	// middleware and the route's merged metadata, set when the router is
+	// frozen.
+	compiled         Handler[T]
+	compiledMetadata map[string]any
+	// compiledResponders and compiledGroupMetadata are the router's empty
+	// responses wrapped by the middleware of the groups the route was
+	// registered through and the merged metadata of those groups. They're
+	// used for responses the router writes for the route's path, like 405s.
+	compiledResponders    map[int]Handler[T]
+	compiledGroupMetadata map[string]any
+	// version is the API version of routes registered through a
+	// VersionedGroup and requestedVersion returns the version a request
+	// asks for, if it specifies one.
+	version          int
+	requestedVersion func(req *http.Request, reqParts []string) (int, bool)
+	// aliases are routes registered for the same handler under another path,
+	// which are configured along with the route.
+	aliases []*Route[T]
+	// streaming is set by Stream.
+	streaming bool
 }
 
-func (r *route[C]) match(req *http.Request) (bool, map[string]string) {
-	if r.Method != req.Method {
-		return false, nil
+// Name sets the name of the route so that URLs for it can be generated using
+// Router.URL.
+func (r *Route[C]) Name(name string) *Route[C] {
+	r.ensureUncompiled(fmt.Sprintf("name route %s %s", r.Method, r.Path))
+	r.name = name
+	return r
+}
+
+// Use registers middleware that will only run for this route. Route
+// middleware runs after the middleware of the router and groups the route was
+// registered through, and before the handler. For controllers, it runs before
+// FromRequest is called.
+func (r *Route[C]) Use(fns ...func(context.Context, C, Handler[C])) *Route[C] {
+	r.ensureUncompiled(fmt.Sprintf("register middleware for route %s %s", r.Method, r.Path))
+	r.routeMiddleware = append(r.routeMiddleware, fns...)
+
+	for _, alias := range r.aliases {
+		alias.Use(fns...)
+	}
+
+	return r
+}
+
+// Meta sets a metadata value for the route that can be retrieved from the
+// RequestContext in middleware and handlers using Meta or
+// RequestContext.Metadata. Route metadata overrides group metadata with the
+// same key.
+func (r *Route[C]) Meta(key string, value any) *Route[C] {
+	r.ensureUncompiled(fmt.Sprintf("set metadata for route %s %s", r.Method, r.Path))
+
+	if r.routeMetadata == nil {
+		r.routeMetadata = make(map[string]any)
+	}
+
+	r.routeMetadata[key] = value
+
+	for _, alias := range r.aliases {
+		alias.Meta(key, value)
+	}
+
+	return r
+}
+
+// Stream makes the route stream its responses, as if Response.Stream was
+// called before the route's middleware runs. Writes go straight to the
+// client instead of being buffered until the handler returns.
+func (r *Route[C]) Stream() *Route[C] {
+	r.ensureUncompiled(fmt.Sprintf("stream route %s %s", r.Method, r.Path))
+	r.streaming = true
+
+	for _, alias := range r.aliases {
+		alias.Stream()
+	}
+
+	return r
+}
+
+// Disable removes the route from the router, so requests are handled as if
+// the route was never registered. Disabled routes are still included in
+// Routes and can still be used to generate URLs. Once the router is serving
+// requests, Disable must be called from a function passed to Router.Update.
+func (r *Route[C]) Disable() *Route[C] {
+	r.ensureMutable(fmt.Sprintf("disable route %s %s", r.Method, r.Path))
+	r.disabled.Store(true)
+
+	for _, alias := range r.aliases {
+		alias.Disable()
+	}
+
+	return r
+}
+
+// Enable adds a route removed by Disable back to the router. Once the router
+// is serving requests, Enable must be called from a function passed to
+// Router.Update.
+func (r *Route[C]) Enable() *Route[C] {
+	r.ensureMutable(fmt.Sprintf("enable route %s %s", r.Method, r.Path))
+	r.disabled.Store(false)
+
+	for _, alias := range r.aliases {
+		alias.Enable()
+	}
+
+	return r
+}
+
+// ensureUncompiled panics if the route has been compiled, since routes are
+// compiled once and changes to them would be ignored.
+func (r *Route[C]) ensureUncompiled(action string) {
+	if r.compiled != nil {
+		panic(fmt.Errorf("%w: cannot %s after the router has started serving requests", ErrFrozen, action))
+	}
+
+	r.ensureMutable(action)
+}
+
+// metadata returns the merged metadata of the route and the groups it was
+// registered through. Inner groups override outer groups and the route
+// overrides all groups. Nil is returned if the route has no metadata.
+func (r *Route[C]) metadata() map[string]any {
+	metadata := r.groupMetadataMerged()
+	for key, value := range r.routeMetadata {
+		if metadata == nil {
+			metadata = make(map[string]any)
+		}
+		metadata[key] = value
+	}
+
+	return metadata
+}
+
+// groupMetadataMerged returns the merged metadata of the groups the route was
+// registered through, with inner groups overriding outer groups. Nil is
+// returned if the groups have no metadata.
+func (r *Route[C]) groupMetadataMerged() map[string]any {
+	var metadata map[string]any
+	for _, groupMetadata := range r.groupMetadata {
+		for key, value := range groupMetadata {
+			if metadata == nil {
+				metadata = make(map[string]any)
+			}
+			metadata[key] = value
+		}
+	}
+
+	return metadata
+}
+
+// addGroupMetadata adds the metadata of a group the route was registered
+// through, overriding the metadata of groups added before it.
+func (r *Route[C]) addGroupMetadata(metadata map[string]any) {
+	r.groupMetadata = append(r.groupMetadata, metadata)
+
+	for _, alias := range r.aliases {
+		alias.addGroupMetadata(metadata)
+	}
+}
+
+// addMiddlewareStack adds a middleware stack that will be run after the
+// existing stacks of the route and before the handler.
+func (r *Route[C]) addMiddlewareStack(stack *middlewareStack[C]) {
+	r.stacks = append(r.stacks, stack)
+
+	for _, alias := range r.aliases {
+		alias.addMiddlewareStack(stack)
+	}
+}
+
+// middleware returns the middleware of every stack of the route, in the order
+// it will be run.
+func (r *Route[C]) middleware() []*middlewareEntry[C] {
+	middleware := r.groupMiddleware()
+	for _, fn := range r.routeMiddleware {
+		middleware = append(middleware, &middlewareEntry[C]{fn: fn})
+	}
+
+	return middleware
+}
+
+// groupMiddleware returns the middleware of the router and groups the route
+// was registered through, excluding the middleware registered for only the
+// route.
+func (r *Route[C]) groupMiddleware() []*middlewareEntry[C] {
+	middleware := make([]*middlewareEntry[C], 0)
+	for _, stack := range r.stacks {
+		middleware = append(middleware, stack.resolve()...)
+	}
+
+	return middleware
+}
+
+// compile returns the handler of the route wrapped by its middleware.
+func (r *Route[C]) compile() Handler[C] {
+	handler := chain(r.middleware(), r.handler)
+
+	if r.streaming {
+		next := handler
+		handler = func(ctx context.Context, rctx C) {
+			rctx.Response().Stream()
+			next(ctx, rctx)
+		}
+	}
+
+	return handler
+}
+
+// freeze precompiles the route's middleware chains and metadata so they
+// aren't rebuilt on every request.
+func (r *Route[C]) freeze() {
+	r.compiled = r.compile()
+	r.compiledMetadata = r.metadata()
+	r.compiledResponders = compileEmptyResponses(r.groupMiddleware(), routeStatuses)
+	r.compiledGroupMetadata = r.groupMetadataMerged()
+}
+
+// info returns the RouteInfo describing the route.
+func (r *Route[C]) info() RouteInfo {
+	middleware := r.middleware()
+	names := make([]string, len(middleware))
+	for i, m := range middleware {
+		names[i] = m.displayName()
+	}
+
+	var matchers []string
+	for _, matcher := range r.matchers {
+		matchers = append(matchers, matcher.description)
+	}
+
+	method := r.Method
+	if method == anyMethod {
+		method = "*"
 	}
 
-	reqParts := normalizeRoutePath(req.URL.Path)
+	return RouteInfo{
+		Method:     method,
+		Host:       r.host,
+		Path:       r.Path,
+		Name:       r.name,
+		File:       r.source,
+		Line:       r.line,
+		Middleware: names,
+		Metadata:   r.metadata(),
+		Matchers:   matchers,
+		Disabled:   r.disabled.Load(),
+		Mount:      r.Method == anyMethod,
+	}
+}
+
+// url returns the path for the route, replacing named and wildcard segments
+// with the escaped values in params.
+func (r *Route[C]) url(params map[string]string) (string, error) {
+	segments := make([]string, len(r.parts))
+	used := 0
+
+	for i, part := range r.parts {
+		if pattern := r.params[i]; pattern != nil {
+			values := make([]string, len(pattern.Names))
+			for j, name := range pattern.Names {
+				value, ok := params[name]
+				if !ok {
+					return "", fmt.Errorf("missing param %q for route %q", name, r.name)
+				}
+
+				if !pattern.MatchesParam(j, value) {
+					return "", fmt.Errorf("invalid value %q for param %q of route %q", value, name, r.name)
+				}
+
+				values[j] = value
+			}
+
+			segment, ok := pattern.Build(values, url.PathEscape)
+			if !ok {
+				return "", fmt.Errorf("values %q for params %q of route %q are ambiguous", values, pattern.Names, r.name)
+			}
 
-	if len(r.parts) != len(reqParts) {
+			used += len(values)
+			segments[i] = segment
+		} else if strings.HasPrefix(part, "*") {
+			value, ok := params[part[1:]]
+			if !ok {
+				return "", fmt.Errorf("missing param %q for route %q", part[1:], r.name)
+			}
+
+			used++
+			wildcardParts := strings.Split(value, "/")
+			for j, wildcardPart := range wildcardParts {
+				wildcardParts[j] = url.PathEscape(wildcardPart)
+			}
+			segments[i] = strings.Join(wildcardParts, "/")
+		} else {
+			segments[i] = part
+		}
+	}
+
+	if used != len(params) {
+		return "", fmt.Errorf("unknown params provided for route %q", r.name)
+	}
+
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+func (r *Route[C]) match(req *http.Request) (bool, map[string]string) {
+	if !r.matchesMethod(req.Method) {
+		return false, nil
+	}
+
+	params, ok := r.capture(req.URL.Path, normalizeRoutePath(req.URL.Path), nil)
+	if !ok {
 		return false, nil
 	}
 
-	params := make(map[string]string)
+	return true, params.Map()
+}
+
+// capture appends the params of the route captured from the request path to
+// params, replacing params that have already been captured with the same
+// name. reqParts must be the normalized segments of path.
+func (r *Route[C]) capture(path string, reqParts []string, params Params) (Params, bool) {
+	if r.isWildcard() {
+		if len(reqParts) < len(r.parts) {
+			return params, false
+		}
+	} else if len(r.parts) != len(reqParts) {
+		return params, false
+	}
+
+	// offset tracks the start of the current segment in path so wildcard
+	// values can be sliced from it instead of joining the segments.
+	path = strings.TrimPrefix(path, "/")
+	offset := 0
 
 	for i, part := range r.parts {
-		if strings.HasPrefix(part, ":") {
-			params[part[1:]] = reqParts[i]
+		if pattern := r.params[i]; pattern != nil {
+			if pattern.IsSingle() {
+				if !pattern.MatchesParam(0, reqParts[i]) {
+					return params, false
+				}
+
+				params = params.set(pattern.Names[0], reqParts[i])
+			} else {
+				values, ok := pattern.Match(reqParts[i])
+				if !ok {
+					return params, false
+				}
+
+				for j, name := range pattern.Names {
+					params = params.set(name, values[j])
+				}
+			}
 		} else if strings.HasPrefix(part, "*") {
-			params[part[1:]] = strings.Join(reqParts[i:], "/")
+			params = params.set(part[1:], path[offset:])
 		} else if part != reqParts[i] {
-			return false, nil
+			return params, false
+		}
+
+		offset += len(reqParts[i]) + 1
+	}
+
+	return params, true
+}
+
+// matchesMethod returns true if the route can handle requests with the given
+// method.
+func (r *Route[C]) matchesMethod(method string) bool {
+	switch r.Method {
+	case method, anyMethod:
+		return true
+	case http.MethodGet:
+		return method == http.MethodHead
+	default:
+		return false
+	}
+}
+
+// canonicalPath returns the path of the request using the registered casing
+// of the route's static segments. reqParts must match the route when compared
+// case-insensitively.
+func (r *Route[C]) canonicalPath(reqParts []string) string {
+	segments := make([]string, 0, len(reqParts))
+
+	for i, part := range r.parts {
+		switch {
+		case r.params[i] != nil:
+			segments = append(segments, reqParts[i])
+		case strings.HasPrefix(part, "*"):
+			segments = append(segments, reqParts[i:]...)
+		default:
+			segments = append(segments, part)
 		}
 	}
 
-	return true, params
+	return "/" + strings.Join(segments, "/")
 }
 
-func (r *route[C]) isWildcard() bool {
-	return r.parts[len(r.parts)-1] == "*"
+// isWildcard returns true if the last segment of the route is a wildcard
+// segment that matches the remainder of the path.
+func (r *Route[C]) isWildcard() bool {
+	return strings.HasPrefix(r.parts[len(r.parts)-1], "*")
 }
 
-func newRoute[T RequestContext](method string, path string, handler Handler[T]) *route[T] {
+func newRoute[T RequestContext](method string, path string, handler Handler[T]) *Route[T] {
+	path = cleanPath(path)
 	parts := normalizeRoutePath(path)
+	params := make([]*radical.Pattern, len(parts))
+
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "*"):
+		case radical.IsPattern(part):
+			params[i] = radical.ParsePattern(part)
+		default:
+			// Static segments are stored as the literal text they match.
+			parts[i] = radical.Unescape(part)
+		}
+	}
+
+	return &Route[T]{
+		Method:        method,
+		Path:          path,
+		parts:         parts,
+		params:        params,
+		handler:       handler,
+		ensureMutable: func(string) {},
+	}
+}
+
+// cleanPath returns the canonical form of path by collapsing repeated slashes
+// and resolving `.` and `..` elements. Trailing slashes are preserved.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	if p[0] != '/' {
+		p = "/" + p
+	}
 
-	// TODO better support for `/`, remove double `//`
-	return &route[T]{
-		Method:  method,
-		Path:    path,
-		parts:   parts,
-		handler: handler,
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
 	}
+
+	return cleaned
 }
 
 func normalizeRoutePath(path string) []string {
+	return appendRoutePath(nil, path)
+}
+
+// appendRoutePath appends the normalized segments of path to parts, allowing
+// the segments of request paths to be split into a reused buffer.
+func appendRoutePath(parts []string, path string) []string {
 	path = strings.TrimPrefix(path, "/")
-	return strings.Split(path, "/")
+
+	for {
+		i := strings.IndexByte(path, '/')
+		if i == -1 {
+			return append(parts, path)
+		}
+
+		parts = append(parts, path[:i])
+		path = path[i+1:]
+	}
 }
@@ -0,0 +1,137 @@
+package fernet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a route registered with a Router. It's returned by
+// Router.Routes and can be rendered using WriteRouteTable or WriteRouteJSON.
+type RouteInfo struct {
+	// Method is the HTTP method of the route.
+	Method string `json:"method"`
+	// Host is the host pattern the route is restricted to, if any.
+	Host string `json:"host,omitempty"`
+	// Path is the full path of the route, including namespace prefixes.
+	Path string `json:"path"`
+	// Name is the name of the route, if it was named.
+	Name string `json:"name,omitempty"`
+	// File is the file the route was registered in.
+	File string `json:"file"`
+	// Line is the line the route was registered on.
+	Line int `json:"line"`
+	// Middleware is the name of each middleware function that will run for
+	// the route, in the order they will run.
+	Middleware []string `json:"middleware"`
+	// Metadata is the metadata of the route, including metadata inherited
+	// from the groups it was registered through.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// Matchers describes the matchers a request must satisfy, in addition to
+	// the method and path, for the route to handle it.
+	Matchers []string `json:"matchers,omitempty"`
+	// Disabled is true if the route has been removed from the router by
+	// Route.Disable.
+	Disabled bool `json:"disabled,omitempty"`
+	// Mount is true if the route was registered by Mount. Mounts handle every
+	// method, so their Method is "*".
+	Mount bool `json:"mount,omitempty"`
+}
+
+// displayMethod returns the method of the route for display, using MOUNT for
+// mounts so they aren't mistaken for routes of a specific method.
+func (r RouteInfo) displayMethod() string {
+	if r.Mount {
+		return "MOUNT"
+	}
+
+	return r.Method
+}
+
+// WriteRouteTable writes the routes to w as an aligned text table. Paths are
+// prefixed with the route's host pattern, mounts are listed with the method
+// MOUNT, and source files are displayed relative to the working directory when
+// possible.
+func WriteRouteTable(w io.Writer, routes []RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tSOURCE\tMIDDLEWARE\tMETADATA")
+	for _, route := range routes {
+		metadata := make([]string, 0, len(route.Metadata))
+		for key, value := range route.Metadata {
+			metadata = append(metadata, fmt.Sprintf("%s=%v", key, value))
+		}
+		sort.Strings(metadata)
+
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s:%d\t%s\t%s\n",
+			route.displayMethod(),
+			route.Host+route.Path,
+			route.Name,
+			relativeFile(route.File),
+			route.Line,
+			strings.Join(route.Middleware, ", "),
+			strings.Join(metadata, ", "),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// WriteRouteJSON writes the routes to w as an indented JSON array.
+func WriteRouteJSON(w io.Writer, routes []RouteInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(routes)
+}
+
+// relativeFile returns file relative to the working directory if it's within
+// it, otherwise file is returned unchanged.
+func relativeFile(file string) string {
+	wd, _ := os.Getwd()
+	if rel, err := filepath.Rel(wd, file); err == nil && wd != "" && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+
+	return file
+}
+
+// packageDir is the directory of the fernet package, used to skip frames
+// internal to fernet when determining where a route was registered.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerLocation returns the file and line of the first caller outside of the
+// fernet package.
+func callerLocation() (string, int) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File, frame.Line
+		}
+
+		if !more {
+			return "", 0
+		}
+	}
+}
+
+// funcName returns the fully qualified name of the given function.
+func funcName(fn any) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
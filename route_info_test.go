@@ -0,0 +1,136 @@
+package fernet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func routerMiddleware(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+	next(ctx, r)
+}
+
+func groupMiddleware(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+	next(ctx, r)
+}
+
+func TestRouter_Routes(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(routerMiddleware)
+
+	_, file, line, _ := runtime.Caller(0)
+	router.Get("/", func(context.Context, *RootRequestContext) {}).Name("root")
+
+	group := router.Namespace("/api")
+	group.Use(groupMiddleware)
+	group.Post("/teams/:id", func(context.Context, *RootRequestContext) {})
+
+	routes := router.Routes()
+	require.Len(t, routes, 2)
+
+	require.Equal(t, RouteInfo{
+		Method:     "GET",
+		Path:       "/",
+		Name:       "root",
+		File:       file,
+		Line:       line + 1,
+		Middleware: []string{"github.com/blakewilliams/fernet.routerMiddleware"},
+	}, routes[0])
+
+	require.Equal(t, RouteInfo{
+		Method: "POST",
+		Path:   "/api/teams/:id",
+		File:   file,
+		Line:   line + 5,
+		Middleware: []string{
+			"github.com/blakewilliams/fernet.routerMiddleware",
+			"github.com/blakewilliams/fernet.groupMiddleware",
+		},
+	}, routes[1])
+}
+
+func TestRouter_RoutesController(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	controller := NewController(router, &PostData{})
+	controller.Use(groupMiddleware)
+
+	_, file, line, _ := runtime.Caller(0)
+	controller.Namespace("/posts").Get("/:id", func(context.Context, *RootRequestContext, *PostData) {})
+
+	routes := router.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, "/posts/:id", routes[0].Path)
+	require.Equal(t, file, routes[0].File)
+	require.Equal(t, line+1, routes[0].Line)
+	require.Equal(t, []string{"github.com/blakewilliams/fernet.groupMiddleware"}, routes[0].Middleware)
+}
+
+func TestRouter_RoutesMetadata(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	api := router.Namespace("/api").Meta("auth", true).Meta("role", "user")
+	api.Namespace("/admin").Meta("role", "admin").Get("/users", func(context.Context, *RootRequestContext) {}).Meta("cache", false)
+	router.Get("/", func(context.Context, *RootRequestContext) {})
+
+	routes := router.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, map[string]any{"auth": true, "role": "admin", "cache": false}, routes[0].Metadata)
+	require.Nil(t, routes[1].Metadata)
+}
+
+func TestRouter_RoutesMount(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Namespace("/api").Mount("/v1", http.NotFoundHandler())
+	router.Match("ANY", "/any", func(context.Context, *RootRequestContext) {})
+
+	routes := router.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, "*", routes[0].Method)
+	require.Equal(t, "/api/v1/*", routes[0].Path)
+	require.True(t, routes[0].Mount)
+	require.Equal(t, "ANY", routes[1].Method)
+	require.False(t, routes[1].Mount)
+}
+
+func TestWriteRouteTable(t *testing.T) {
+	wd, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	routes := []RouteInfo{
+		{Method: "GET", Path: "/", Name: "root", File: filepath.Join(wd, "app.go"), Line: 10, Middleware: []string{"a", "b"}},
+		{Method: "DELETE", Path: "/teams/:id", File: "/elsewhere/teams.go", Line: 200, Metadata: map[string]any{"role": "admin", "auth": true}},
+		{Method: "*", Path: "/admin/*", File: "/elsewhere/admin.go", Line: 5, Mount: true},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteRouteTable(&b, routes))
+
+	lines := strings.Split(b.String(), "\n")
+	require.Equal(t, []string{
+		"METHOD  PATH        NAME  SOURCE                   MIDDLEWARE  METADATA",
+		"GET     /           root  app.go:10                a, b        ",
+		"DELETE  /teams/:id        /elsewhere/teams.go:200              auth=true, role=admin",
+		"MOUNT   /admin/*          /elsewhere/admin.go:5                ",
+		"",
+	}, lines)
+}
+
+func TestWriteRouteJSON(t *testing.T) {
+	routes := []RouteInfo{
+		{Method: "GET", Path: "/", Name: "root", File: "app.go", Line: 10, Middleware: []string{"a"}},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteRouteJSON(&b, routes))
+
+	var decoded []RouteInfo
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	require.Equal(t, routes, decoded)
+	require.Contains(t, b.String(), `"method": "GET"`)
+}
@@ -41,6 +41,14 @@ func TestRouteMatching(t *testing.T) {
 			want:        false,
 			params:      nil,
 		},
+		"head matches get": {
+			reqMethod:   "HEAD",
+			reqPath:     "/foo",
+			routeMethod: "GET",
+			routePath:   "/foo",
+			want:        true,
+			params:      map[string]string{},
+		},
 		"valid root": {
 			reqMethod:   "GET",
 			reqPath:     "/",
@@ -89,6 +97,86 @@ func TestRouteMatching(t *testing.T) {
 			want:        true,
 			params:      map[string]string{"name": "greg", "location": "boston"},
 		},
+		"empty dynamic segment": {
+			reqMethod:   "GET",
+			reqPath:     "/hello/",
+			routeMethod: "GET",
+			routePath:   "/hello/:name",
+			want:        false,
+			params:      nil,
+		},
+		"valid constrained route": {
+			reqMethod:   "GET",
+			reqPath:     "/items/42",
+			routeMethod: "GET",
+			routePath:   "/items/:id<int>",
+			want:        true,
+			params:      map[string]string{"id": "42"},
+		},
+		"constraint mismatch": {
+			reqMethod:   "GET",
+			reqPath:     "/items/foo",
+			routeMethod: "GET",
+			routePath:   "/items/:id<int>",
+			want:        false,
+			params:      nil,
+		},
+		"valid file extension route": {
+			reqMethod:   "GET",
+			reqPath:     "/files/archive.tar.gz",
+			routeMethod: "GET",
+			routePath:   "/files/:name.:ext",
+			want:        true,
+			params:      map[string]string{"name": "archive.tar", "ext": "gz"},
+		},
+		"file extension mismatch": {
+			reqMethod:   "GET",
+			reqPath:     "/files/archive",
+			routeMethod: "GET",
+			routePath:   "/files/:name.:ext",
+			want:        false,
+			params:      nil,
+		},
+		"valid literal prefix route": {
+			reqMethod:   "GET",
+			reqPath:     "/v2/items",
+			routeMethod: "GET",
+			routePath:   "/v:version<int>/items",
+			want:        true,
+			params:      map[string]string{"version": "2"},
+		},
+		"literal prefix constraint mismatch": {
+			reqMethod:   "GET",
+			reqPath:     "/vtwo/items",
+			routeMethod: "GET",
+			routePath:   "/v:version<int>/items",
+			want:        false,
+			params:      nil,
+		},
+		"valid multi param route": {
+			reqMethod:   "GET",
+			reqPath:     "/blakewilliams/fernet@main",
+			routeMethod: "GET",
+			routePath:   "/:owner/:repo@:ref",
+			want:        true,
+			params:      map[string]string{"owner": "blakewilliams", "repo": "fernet", "ref": "main"},
+		},
+		"valid wildcard route": {
+			reqMethod:   "GET",
+			reqPath:     "/files/foo/bar",
+			routeMethod: "GET",
+			routePath:   "/files/*path",
+			want:        true,
+			params:      map[string]string{"path": "foo/bar"},
+		},
+		"wildcard length check": {
+			reqMethod:   "GET",
+			reqPath:     "/files",
+			routeMethod: "GET",
+			routePath:   "/files/*path",
+			want:        false,
+			params:      nil,
+		},
 	}
 
 	for name, tc := range tests {
@@ -103,3 +191,21 @@ func TestRouteMatching(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanPath(t *testing.T) {
+	tests := map[string]string{
+		"":             "/",
+		"/":            "/",
+		"foo":          "/foo",
+		"//foo//bar/":  "/foo/bar/",
+		"/foo/./bar":   "/foo/bar",
+		"/foo/../bar/": "/bar/",
+		"/../foo":      "/foo",
+	}
+
+	for path, want := range tests {
+		t.Run(path, func(t *testing.T) {
+			require.Equal(t, want, cleanPath(path))
+		})
+	}
+}
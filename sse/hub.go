@@ -0,0 +1,152 @@
+package sse
+
+import (
+	"strconv"
+	"sync"
+)
+
+type (
+	// Hub fans events out to every subscriber in the process. Published events
+	// are kept in a bounded history so that clients reconnecting with a
+	// Last-Event-ID receive the events they missed.
+	//
+	// Subscribers that fall too far behind are unsubscribed and have their
+	// channel closed, so the client reconnects and catches up using the
+	// history instead of slowing down every other subscriber.
+	Hub struct {
+		mu          sync.Mutex
+		subscribers map[chan Event]struct{}
+		history     []Event
+		options     hubOptions
+		nextID      uint64
+		closed      bool
+	}
+
+	// HubOption configures a Hub.
+	HubOption func(*hubOptions)
+
+	hubOptions struct {
+		historySize int
+		bufferSize  int
+	}
+)
+
+// WithHistory sets the number of published events kept for replaying to
+// reconnecting clients. It defaults to 100.
+func WithHistory(size int) HubOption {
+	return func(o *hubOptions) {
+		o.historySize = size
+	}
+}
+
+// WithBufferSize sets the number of events buffered for each subscriber
+// before it's considered too slow and unsubscribed. It defaults to 16.
+func WithBufferSize(size int) HubOption {
+	return func(o *hubOptions) {
+		o.bufferSize = size
+	}
+}
+
+// NewHub returns a new Hub.
+func NewHub(opts ...HubOption) *Hub {
+	o := hubOptions{historySize: 100, bufferSize: 16}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Hub{
+		subscribers: make(map[chan Event]struct{}),
+		options:     o,
+	}
+}
+
+// Publish sends the event to every subscriber and adds it to the history.
+// Events without an ID are assigned an incrementing numeric ID so they can be
+// replayed. The published event is returned.
+func (h *Hub) Publish(event Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return event
+	}
+
+	h.nextID++
+	if event.ID == "" {
+		event.ID = strconv.FormatUint(h.nextID, 10)
+	}
+
+	if h.options.historySize > 0 {
+		if len(h.history) == h.options.historySize {
+			h.history = append(h.history[:0], h.history[1:]...)
+		}
+
+		h.history = append(h.history, event)
+	}
+
+	for subscriber := range h.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			delete(h.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+
+	return event
+}
+
+// Subscribe returns a channel that receives every event published after the
+// event with lastEventID, followed by every event published from now on. If
+// lastEventID is empty or no longer in the history, only new events are
+// received. The returned function unsubscribes and must be called once the
+// subscriber is done.
+func (h *Hub) Subscribe(lastEventID string) (<-chan Event, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []Event
+	if lastEventID != "" {
+		for i, event := range h.history {
+			if event.ID == lastEventID {
+				missed = h.history[i+1:]
+				break
+			}
+		}
+	}
+
+	events := make(chan Event, len(missed)+h.options.bufferSize)
+	for _, event := range missed {
+		events <- event
+	}
+
+	if h.closed {
+		close(events)
+		return events, func() {}
+	}
+
+	h.subscribers[events] = struct{}{}
+
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subscribers[events]; ok {
+			delete(h.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// Close unsubscribes every subscriber, closing their channels so that Serve
+// returns. Events published after Close are dropped.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for subscriber := range h.subscribers {
+		delete(h.subscribers, subscriber)
+		close(subscriber)
+	}
+}
@@ -0,0 +1,97 @@
+package sse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub()
+
+	first, unsubscribeFirst := hub.Subscribe("")
+	second, unsubscribeSecond := hub.Subscribe("")
+	defer unsubscribeSecond()
+
+	event := hub.Publish(Event{Data: "hello"})
+	require.Equal(t, "1", event.ID)
+	require.Equal(t, event, <-first)
+	require.Equal(t, event, <-second)
+
+	unsubscribeFirst()
+	_, ok := <-first
+	require.False(t, ok)
+
+	event = hub.Publish(Event{ID: "custom", Data: "world"})
+	require.Equal(t, "custom", event.ID)
+	require.Equal(t, event, <-second)
+}
+
+func TestHub_Replay(t *testing.T) {
+	testCases := map[string]struct {
+		lastEventID string
+		expected    []string
+	}{
+		"no last event id": {lastEventID: "", expected: nil},
+		"in history":       {lastEventID: "3", expected: []string{"4", "5"}},
+		"latest event":     {lastEventID: "5", expected: nil},
+		"evicted":          {lastEventID: "1", expected: nil},
+		"unknown":          {lastEventID: "unknown", expected: nil},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			hub := NewHub(WithHistory(3))
+			for i := 0; i < 5; i++ {
+				hub.Publish(Event{Data: "event"})
+			}
+
+			events, unsubscribe := hub.Subscribe(tc.lastEventID)
+			unsubscribe()
+
+			var ids []string
+			for event := range events {
+				ids = append(ids, event.ID)
+			}
+
+			require.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := NewHub(WithBufferSize(1))
+
+	slow, unsubscribeSlow := hub.Subscribe("")
+	defer unsubscribeSlow()
+
+	hub.Publish(Event{Data: "one"})
+	hub.Publish(Event{Data: "two"})
+
+	// The slow subscriber receives what was buffered before it was dropped.
+	require.Equal(t, "one", (<-slow).Data)
+	_, ok := <-slow
+	require.False(t, ok)
+
+	// Subscribers that were dropped can catch up using the history.
+	caughtUp, unsubscribe := hub.Subscribe("1")
+	defer unsubscribe()
+	require.Equal(t, "two", (<-caughtUp).Data)
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub()
+	events, unsubscribe := hub.Subscribe("")
+	defer unsubscribe()
+
+	hub.Close()
+	_, ok := <-events
+	require.False(t, ok)
+
+	hub.Publish(Event{Data: "dropped"})
+
+	events, unsubscribe = hub.Subscribe("")
+	defer unsubscribe()
+	_, ok = <-events
+	require.False(t, ok)
+}
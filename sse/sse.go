@@ -0,0 +1,212 @@
+// Package sse implements Server-Sent Events for fernet handlers. Streams write
+// events to a streaming fernet response, and Hub fans events out to every
+// subscribed stream.
+package sse
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/fernet"
+)
+
+// ErrInvalidEvent is returned when the ID or name of an event contains a
+// newline, which can't be represented in the event stream format.
+var ErrInvalidEvent = errors.New("sse: event id and name must not contain newlines")
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID is written to the `id` field and sent back by clients in the
+	// Last-Event-ID header when they reconnect.
+	ID string
+	// Name is written to the `event` field. Clients treat events without a
+	// name as `message` events.
+	Name string
+	// Data is written to the `data` field, using a separate field for each
+	// line.
+	Data string
+	// Retry is written to the `retry` field, telling clients how long to wait
+	// before reconnecting. It's omitted if zero.
+	Retry time.Duration
+}
+
+// Stream writes Server-Sent Events to the response of a request.
+type Stream struct {
+	res         fernet.Response
+	lastEventID string
+}
+
+// NewStream switches the response of rctx to streaming mode, sends the
+// `text/event-stream` headers, and returns a Stream for sending events. The
+// stream must not be used after the handler returns.
+func NewStream(rctx fernet.RequestContext) *Stream {
+	res := rctx.Response()
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	// Proxies like nginx buffer responses unless they're told not to.
+	res.Header().Set("X-Accel-Buffering", "no")
+
+	res.Stream()
+	res.WriteHeader(200)
+	_, _ = res.Flush()
+
+	return &Stream{
+		res:         res,
+		lastEventID: rctx.Request().Header.Get("Last-Event-ID"),
+	}
+}
+
+// LastEventID returns the ID of the last event the client received before
+// reconnecting, taken from the Last-Event-ID header of the request.
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send writes the event to the client and flushes it.
+func (s *Stream) Send(event Event) error {
+	if strings.ContainsAny(event.ID, "\r\n") || strings.ContainsAny(event.Name, "\r\n") {
+		return ErrInvalidEvent
+	}
+
+	var b strings.Builder
+
+	if event.ID != "" {
+		writeField(&b, "id", event.ID)
+	}
+
+	if event.Name != "" {
+		writeField(&b, "event", event.Name)
+	}
+
+	if event.Retry > 0 {
+		writeField(&b, "retry", strconv.FormatInt(event.Retry.Milliseconds(), 10))
+	}
+
+	// Events that only set the reconnection time don't need to be
+	// dispatched by the client.
+	if event.Data != "" || event.ID != "" || event.Name != "" {
+		data := strings.ReplaceAll(event.Data, "\r\n", "\n")
+		data = strings.ReplaceAll(data, "\r", "\n")
+
+		for _, line := range strings.Split(data, "\n") {
+			writeField(&b, "data", line)
+		}
+	}
+
+	b.WriteByte('\n')
+
+	return s.write(b.String())
+}
+
+// Comment writes a comment line to the client, which is ignored by clients
+// but keeps the connection open.
+func (s *Stream) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		b.WriteString(": ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+
+	return s.write(b.String())
+}
+
+func (s *Stream) write(frame string) error {
+	if _, err := s.res.Write([]byte(frame)); err != nil {
+		return err
+	}
+
+	_, err := s.res.Flush()
+	return err
+}
+
+func writeField(b *strings.Builder, name string, value string) {
+	b.WriteString(name)
+	b.WriteString(": ")
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+type (
+	// Option configures Serve.
+	Option func(*options)
+
+	options struct {
+		heartbeat time.Duration
+		retry     time.Duration
+	}
+)
+
+// WithHeartbeat sends a comment every interval while no events are being
+// sent, so proxies and clients don't close idle connections. Heartbeats are
+// sent every 15 seconds by default and are disabled if interval is zero.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(o *options) {
+		o.heartbeat = interval
+	}
+}
+
+// WithRetry tells the client how long to wait before reconnecting when the
+// connection is lost.
+func WithRetry(retry time.Duration) Option {
+	return func(o *options) {
+		o.retry = retry
+	}
+}
+
+// Serve streams the events received from events to the client of rctx until
+// events is closed or ctx is cancelled, e.g. because the client disconnected.
+// The error of ctx is returned if it was cancelled, and otherwise the first
+// error writing to the client.
+//
+//	router.Get("/events", func(ctx context.Context, r *AppContext) {
+//		events, unsubscribe := hub.Subscribe(r.Request().Header.Get("Last-Event-ID"))
+//		defer unsubscribe()
+//
+//		_ = sse.Serve(ctx, r, events)
+//	})
+func Serve(ctx context.Context, rctx fernet.RequestContext, events <-chan Event, opts ...Option) error {
+	o := options{heartbeat: 15 * time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	stream := NewStream(rctx)
+
+	if o.retry > 0 {
+		if err := stream.Send(Event{Retry: o.retry}); err != nil {
+			return err
+		}
+	}
+
+	var heartbeat <-chan time.Time
+	if o.heartbeat > 0 {
+		ticker := time.NewTicker(o.heartbeat)
+		defer ticker.Stop()
+
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-heartbeat:
+			if err := stream.Comment("heartbeat"); err != nil {
+				return err
+			}
+		}
+	}
+}
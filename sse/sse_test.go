@@ -0,0 +1,168 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/fernet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_Send(t *testing.T) {
+	testCases := map[string]struct {
+		event    Event
+		expected string
+	}{
+		"data":            {event: Event{Data: "hello"}, expected: "data: hello\n\n"},
+		"all fields":      {event: Event{ID: "1", Name: "update", Data: "hello", Retry: 3 * time.Second}, expected: "id: 1\nevent: update\nretry: 3000\ndata: hello\n\n"},
+		"multi-line data": {event: Event{Data: "one\ntwo\r\nthree\rfour"}, expected: "data: one\ndata: two\ndata: three\ndata: four\n\n"},
+		"empty data":      {event: Event{Name: "ping"}, expected: "event: ping\ndata: \n\n"},
+		"retry only":      {event: Event{Retry: time.Second}, expected: "retry: 1000\n\n"},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			router := newRouter()
+			router.Get("/events", func(ctx context.Context, r fernet.RequestContext) {
+				require.NoError(t, NewStream(r).Send(tc.event))
+			})
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+			require.Equal(t, http.StatusOK, res.Code)
+			require.Equal(t, "text/event-stream", res.Header().Get("Content-Type"))
+			require.Equal(t, "no-cache", res.Header().Get("Cache-Control"))
+			require.Equal(t, tc.expected, res.Body.String())
+		})
+	}
+}
+
+func TestStream_InvalidEvent(t *testing.T) {
+	router := newRouter()
+	router.Get("/events", func(ctx context.Context, r fernet.RequestContext) {
+		stream := NewStream(r)
+		require.ErrorIs(t, stream.Send(Event{ID: "1\n2", Data: "hello"}), ErrInvalidEvent)
+		require.ErrorIs(t, stream.Send(Event{Name: "a\rb", Data: "hello"}), ErrInvalidEvent)
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	require.Empty(t, res.Body.String())
+}
+
+func TestStream_LastEventID(t *testing.T) {
+	router := newRouter()
+	router.Get("/events", func(ctx context.Context, r fernet.RequestContext) {
+		stream := NewStream(r)
+		require.NoError(t, stream.Comment(stream.LastEventID()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "42")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, ": 42\n\n", res.Body.String())
+}
+
+func TestServe(t *testing.T) {
+	hub := NewHub()
+	done := make(chan error, 1)
+
+	router := newRouter()
+	router.Get("/events", func(ctx context.Context, r fernet.RequestContext) {
+		events, unsubscribe := hub.Subscribe(r.Request().Header.Get("Last-Event-ID"))
+		defer unsubscribe()
+
+		done <- Serve(ctx, r, events, WithHeartbeat(10*time.Millisecond), WithRetry(time.Second))
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	hub.Publish(Event{Data: "missed"})
+	hub.Publish(Event{Data: "replayed"})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+	heartbeats := 0
+	readEvent := func() string {
+		var frame strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+
+			switch {
+			case line == ": heartbeat\n":
+				heartbeats++
+			case line == "\n" && frame.Len() > 0:
+				return frame.String()
+			case line != "\n":
+				frame.WriteString(line)
+			}
+		}
+	}
+
+	require.Equal(t, "retry: 1000\n", readEvent())
+	require.Equal(t, "id: 2\ndata: replayed\n", readEvent())
+
+	hub.Publish(Event{Name: "update", Data: "live"})
+	require.Equal(t, "id: 3\nevent: update\ndata: live\n", readEvent())
+
+	// Heartbeats are sent while no events are published.
+	time.Sleep(50 * time.Millisecond)
+	hub.Publish(Event{Data: "after heartbeats"})
+	require.Equal(t, "id: 4\ndata: after heartbeats\n", readEvent())
+	require.Positive(t, heartbeats)
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		require.Fail(t, "Serve did not return after the client disconnected")
+	}
+}
+
+func TestServe_ClosedChannel(t *testing.T) {
+	hub := NewHub()
+
+	router := newRouter()
+	router.Get("/events", func(ctx context.Context, r fernet.RequestContext) {
+		events, unsubscribe := hub.Subscribe("")
+		defer unsubscribe()
+
+		hub.Publish(Event{Data: "last"})
+		hub.Close()
+
+		require.NoError(t, Serve(ctx, r, events, WithHeartbeat(0)))
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	require.Equal(t, "id: 1\ndata: last\n\n", res.Body.String())
+}
+
+func newRouter() *fernet.Router[fernet.RequestContext] {
+	return fernet.New(func(r fernet.RequestContext) fernet.RequestContext { return r })
+}
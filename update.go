@@ -0,0 +1,151 @@
+package fernet
+
+import (
+	"strings"
+
+	"github.com/blakewilliams/fernet/internal/radical"
+)
+
+// routeTable is the compiled, read-only set of routes a router serves
+// requests from. Tables are never modified once they're built, so requests
+// that started before a table is replaced by Update keep using the old table
+// until they finish.
+type routeTable[T RequestContext] struct {
+	// routes is every route registered when the table was built, including
+	// disabled routes.
+	routes []*Route[T]
+	// hosts are the route trees of each host pattern and rootTrees contains
+	// the host-agnostic tree. rootTrees is returned by treesFor when there
+	// are no hosts, so it doesn't need to be allocated for each request.
+	hosts     []*hostTree[T]
+	rootTrees []matchedTree[T]
+	// candidates are the enabled routes that share a method and path with
+	// other routes or use matchers or versions, in registration order, keyed
+	// by the first of them which is the route stored in the tree. They're
+	// selected between using the matchers and versions of each route.
+	candidates map[*Route[T]][]*Route[T]
+}
+
+// routeSlot identifies the node of a route tree a route is stored in.
+type routeSlot[T RequestContext] struct {
+	tree  *radical.Node[*Route[T]]
+	shape string
+}
+
+// Update applies changes to the routes of the router while it's serving
+// requests. Routes, hosts, and fallback handlers can be registered and routes
+// can be enabled or disabled from fn, e.g. to serve feature flagged endpoints
+// or routes loaded from plugins:
+//
+//	router.Update(func() {
+//		router.Get("/beta", betaHandler)
+//		legacyRoute.Disable()
+//	})
+//
+// Once fn returns, the new routes are compiled and the route tree used by the
+// router is atomically replaced. Requests that are already being handled keep
+// using the previous routes. Middleware and metadata of the router and its
+// groups can't be changed once the router is frozen, but new routes can use
+// Route.Use, Route.Meta, and Route.Name before fn returns.
+//
+// Updates are applied one at a time. Routes must not be registered, enabled,
+// or disabled from other goroutines while fn is running. If the router hasn't
+// been frozen yet, fn is called and its changes are applied when the router is
+// frozen.
+func (r *Router[T]) Update(fn func()) {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	r.updating.Store(true)
+	defer r.updating.Store(false)
+
+	fn()
+
+	if r.frozen.Load() {
+		r.table.Store(r.compileTable())
+	}
+}
+
+// compileTable compiles the routes that haven't been compiled yet and builds
+// a new route table from every enabled route. Routes are added to the trees
+// in the order they were registered, and routes sharing a method and path
+// become candidates of the first of them.
+func (r *Router[T]) compileTable() *routeTable[T] {
+	table := &routeTable[T]{
+		routes:     append([]*Route[T](nil), r.routes...),
+		hosts:      make([]*hostTree[T], 0, len(r.hosts)),
+		candidates: make(map[*Route[T]][]*Route[T]),
+	}
+
+	root := radical.New[*Route[T]]()
+	table.rootTrees = []matchedTree[T]{{tree: root}}
+
+	slots := make(map[routeSlot[T]]*Route[T], len(table.routes))
+	hostTrees := make(map[*hostPattern]*radical.Node[*Route[T]], len(r.hosts))
+	for _, host := range r.hosts {
+		tree := radical.New[*Route[T]]()
+		hostTrees[host] = tree
+		table.hosts = append(table.hosts, &hostTree[T]{pattern: *host, tree: tree})
+	}
+
+	for _, route := range table.routes {
+		if route.compiled == nil {
+			route.freeze()
+		}
+
+		if route.disabled.Load() {
+			continue
+		}
+
+		tree := root
+		if route.hostPattern != nil {
+			tree = hostTrees[route.hostPattern]
+		}
+
+		pathParts := make([]string, 0, len(route.parts)+1)
+		pathParts = append(pathParts, route.Method)
+		for i := range route.parts {
+			pathParts = append(pathParts, route.shape(i))
+		}
+
+		slot := routeSlot[T]{tree: tree, shape: strings.Join(pathParts, "/")}
+		if first, ok := slots[slot]; ok {
+			if _, ok := table.candidates[first]; !ok {
+				table.candidates[first] = []*Route[T]{first}
+			}
+
+			table.candidates[first] = append(table.candidates[first], route)
+			continue
+		}
+
+		slots[slot] = route
+		if len(route.matchers) > 0 || route.version > 0 {
+			table.candidates[route] = []*Route[T]{route}
+		}
+
+		pathParts = pathParts[:1]
+		for i, part := range route.parts {
+			if route.params[i] == nil && !strings.HasPrefix(part, "*") {
+				part = radical.Escape(part)
+			}
+
+			pathParts = append(pathParts, part)
+		}
+
+		// Conflicting routes are kept out of the tree and reported by Validate.
+		_ = tree.Add(pathParts, route)
+	}
+
+	return table
+}
+
+// currentRoutes returns the registered routes of the router. Once the router
+// is frozen the routes of the current route table are returned, so they can
+// be read while Update registers new routes.
+func (r *Router[T]) currentRoutes() []*Route[T] {
+	if table := r.table.Load(); table != nil {
+		return table.routes
+	}
+
+	return r.routes
+}
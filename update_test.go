@@ -0,0 +1,263 @@
+package fernet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Update(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	respond := func(body string) Handler[*RootRequestContext] {
+		return func(ctx context.Context, r *RootRequestContext) {
+			_, _ = r.Response().Write([]byte(body))
+		}
+	}
+
+	router.Get("/", respond("root"))
+	legacy := router.Get("/users/:id", respond("legacy"))
+	api := router.Namespace("/api")
+	api.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		r.Response().Header().Set("X-API", "true")
+		next(ctx, r)
+	})
+
+	serve := func(method string, path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+		return res
+	}
+
+	require.Equal(t, http.StatusNotFound, serve("GET", "/api/beta").Code)
+
+	var current *Route[*RootRequestContext]
+	router.Update(func() {
+		api.Get("/beta", respond("beta")).Name("beta")
+		current = router.Get("/users/:user_id", respond("current"))
+		legacy.Disable()
+	})
+
+	res := serve("GET", "/api/beta")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "beta", res.Body.String())
+	require.Equal(t, "true", res.Header().Get("X-API"))
+	require.Equal(t, "current", serve("GET", "/users/1").Body.String())
+	require.Equal(t, "root", serve("GET", "/").Body.String())
+
+	url, err := router.URL("beta")
+	require.NoError(t, err)
+	require.Equal(t, "/api/beta", url)
+	require.NoError(t, router.Validate())
+
+	router.Update(func() {
+		current.Disable()
+		legacy.Enable()
+	})
+
+	require.Equal(t, "legacy", serve("GET", "/users/1").Body.String())
+
+	router.Update(func() {
+		legacy.Disable()
+	})
+
+	require.Equal(t, http.StatusNotFound, serve("GET", "/users/1").Code)
+
+	routes := router.Routes()
+	require.Len(t, routes, 4)
+	require.True(t, routes[1].Disabled)
+	require.True(t, routes[3].Disabled)
+}
+
+func TestRouter_UpdateHosts(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("default"))
+	})
+	router.Freeze()
+
+	router.Update(func() {
+		router.Host(":tenant.example.com").Get("/", func(ctx context.Context, r *RootRequestContext) {
+			_, _ = r.Response().Write([]byte(r.Param("tenant")))
+		})
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "acme.example.com"
+	router.ServeHTTP(res, req)
+	require.Equal(t, "acme", res.Body.String())
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, "default", res.Body.String())
+}
+
+func TestRouter_UpdateBeforeFreeze(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	route := router.Get("/", func(context.Context, *RootRequestContext) {})
+	router.Update(func() {
+		route.Disable()
+	})
+
+	// Changes outside of Update are allowed until the router is frozen.
+	router.Get("/other", func(context.Context, *RootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouter_UpdateFrozen(t *testing.T) {
+	handler := func(context.Context, *RootRequestContext) {}
+
+	tests := map[string]struct {
+		change func(router *Router[*RootRequestContext], route *Route[*RootRequestContext])
+		want   string
+	}{
+		"disable outside update": {
+			change: func(_ *Router[*RootRequestContext], route *Route[*RootRequestContext]) {
+				route.Disable()
+			},
+			want: "router is frozen: cannot disable route GET /users after the router has started serving requests",
+		},
+		"name compiled route": {
+			change: func(router *Router[*RootRequestContext], route *Route[*RootRequestContext]) {
+				router.Update(func() {
+					route.Name("users")
+				})
+			},
+			want: "router is frozen: cannot name route GET /users after the router has started serving requests",
+		},
+		"router middleware": {
+			change: func(router *Router[*RootRequestContext], _ *Route[*RootRequestContext]) {
+				router.Update(func() {
+					router.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {})
+				})
+			},
+			want: "router is frozen: cannot register middleware after the router has started serving requests",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := New(WithBasicRequestContext)
+			route := router.Get("/users", handler)
+			router.Freeze()
+
+			defer func() {
+				err, ok := recover().(error)
+				require.True(t, ok)
+				require.True(t, errors.Is(err, ErrFrozen))
+				require.EqualError(t, err, tc.want)
+
+				// The router can still be updated after a failed update.
+				router.Update(func() {
+					router.Get("/after", handler)
+				})
+			}()
+
+			tc.change(router, route)
+		})
+	}
+}
+
+func TestRouter_UpdateInFlight(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	route := router.Get("/slow", func(ctx context.Context, r *RootRequestContext) {
+		close(started)
+		<-release
+		_, _ = r.Response().Write([]byte("old"))
+	})
+
+	res := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(res, httptest.NewRequest("GET", "/slow", nil))
+	}()
+
+	<-started
+	router.Update(func() {
+		route.Disable()
+		router.Get("/slow", func(ctx context.Context, r *RootRequestContext) {
+			_, _ = r.Response().Write([]byte("new"))
+		})
+	})
+	close(release)
+	<-done
+
+	require.Equal(t, "old", res.Body.String())
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/slow", nil))
+	require.Equal(t, "new", res.Body.String())
+}
+
+// TestRouter_UpdateConcurrent serves requests while routes are updated and is
+// intended to be run with the race detector.
+func TestRouter_UpdateConcurrent(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	flagged := router.Get("/flagged", func(ctx context.Context, r *RootRequestContext) {
+		_, _ = r.Response().Write([]byte("flagged"))
+	}).Name("flagged")
+	router.Freeze()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, httptest.NewRequest("GET", "/flagged", nil))
+				if res.Code != http.StatusOK && res.Code != http.StatusNotFound {
+					t.Errorf("unexpected status %d", res.Code)
+				}
+
+				if _, err := router.URL("flagged"); err != nil {
+					t.Error(err)
+				}
+
+				_ = router.Routes()
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		router.Update(func() {
+			if i%2 == 0 {
+				flagged.Disable()
+			} else {
+				flagged.Enable()
+			}
+
+			router.Get(fmt.Sprintf("/plugins/%d", i), func(context.Context, *RootRequestContext) {})
+		})
+	}
+
+	close(stop)
+	wg.Wait()
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/plugins/99", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+}
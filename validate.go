@@ -0,0 +1,303 @@
+package fernet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blakewilliams/fernet/internal/radical"
+)
+
+// ConflictKind is the kind of conflict between two routes.
+type ConflictKind int
+
+const (
+	// ConflictDuplicate is reported when two routes have the same method and
+	// path, ignoring param names, and neither uses matchers. Only the first
+	// route registered is used.
+	ConflictDuplicate ConflictKind = iota + 1
+	// ConflictParamName is reported when two routes use different names for
+	// the param at the same position of a shared path prefix, like
+	// `/users/:id` and `/users/:user_id/posts`.
+	ConflictParamName
+	// ConflictWildcardShadow is reported when a wildcard route for a method
+	// matches every path of a route registered for any method, like a mounted
+	// handler, so the wildcard route is used instead for that method.
+	ConflictWildcardShadow
+	// ConflictUnreachable is reported when a route can never match because a
+	// higher priority route matches every path it does, like `/items/:id<int>`
+	// registered after `/items/:slug<.+>`, or because it uses matchers and an
+	// earlier route with the same method and path doesn't.
+	ConflictUnreachable
+)
+
+// String returns a human readable name for the kind of conflict.
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictDuplicate:
+		return "duplicate"
+	case ConflictParamName:
+		return "param name mismatch"
+	case ConflictWildcardShadow:
+		return "wildcard shadowing"
+	case ConflictUnreachable:
+		return "unreachable"
+	default:
+		return "unknown"
+	}
+}
+
+// RouteConflictError describes a route that conflicts with another route. It
+// is returned by Router.Validate.
+type RouteConflictError struct {
+	// Kind is the kind of conflict.
+	Kind ConflictKind
+	// Route is the offending route.
+	Route RouteInfo
+	// Conflicting is the route that Route conflicts with.
+	Conflicting RouteInfo
+	// Param and ConflictingParam are the mismatched param names of
+	// ConflictParamName conflicts.
+	Param            string
+	ConflictingParam string
+}
+
+// Error implements the error interface.
+func (e *RouteConflictError) Error() string {
+	route := describeRoute(e.Route)
+	conflicting := describeRoute(e.Conflicting)
+
+	switch e.Kind {
+	case ConflictDuplicate:
+		return fmt.Sprintf("%s duplicates %s", route, conflicting)
+	case ConflictParamName:
+		return fmt.Sprintf(
+			"%s names param %q but %s names it %q at the same position",
+			route,
+			e.Param,
+			conflicting,
+			e.ConflictingParam,
+		)
+	case ConflictWildcardShadow:
+		return fmt.Sprintf(
+			"%s is shadowed for %s requests by wildcard route %s",
+			route,
+			e.Conflicting.Method,
+			conflicting,
+		)
+	case ConflictUnreachable:
+		return fmt.Sprintf("%s is unreachable because %s matches every request it does", route, conflicting)
+	default:
+		return fmt.Sprintf("%s conflicts with %s", route, conflicting)
+	}
+}
+
+// Validate checks the routes of the router for conflicts and returns an error
+// describing every conflict found, or nil if there are none. Each conflict is
+// a *RouteConflictError that can be retrieved using errors.As.
+//
+// Conflicting routes don't cause a panic when they're registered so Validate
+// should be called once every route has been registered, e.g. at startup or in
+// a test. Routes are only compared with routes of the same host pattern, and
+// disabled routes are ignored.
+func (r *Router[T]) Validate() error {
+	var errs []error
+
+	routes := r.currentRoutes()
+	for i, route := range routes {
+		if isFallbackMethod(route.Method) || route.disabled.Load() {
+			continue
+		}
+
+		for _, other := range routes[:i] {
+			if isFallbackMethod(other.Method) || other.disabled.Load() || other.host != route.host {
+				continue
+			}
+
+			if err := r.conflict(routes, route, other); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// conflict returns the conflict between route and other, which was registered
+// before route, or nil if they don't conflict.
+func (r *Router[T]) conflict(routes []*Route[T], route, other *Route[T]) *RouteConflictError {
+	if route.Method == other.Method {
+		if len(route.parts) == len(other.parts) && route.sharesShape(other, len(route.parts)) {
+			// Routes sharing a path are selected between by their matchers
+			// and versions, so only an earlier route without either conflicts.
+			switch {
+			case len(other.matchers) > 0 || other.version > 0 || route.version > 0:
+				return nil
+			case len(route.matchers) > 0:
+				return &RouteConflictError{Kind: ConflictUnreachable, Route: route.info(), Conflicting: other.info()}
+			default:
+				return &RouteConflictError{Kind: ConflictDuplicate, Route: route.info(), Conflicting: other.info()}
+			}
+		}
+
+		if param, otherParam, ok := route.mismatchedParam(other); ok {
+			return &RouteConflictError{
+				Kind:             ConflictParamName,
+				Route:            route.info(),
+				Conflicting:      other.info(),
+				Param:            param,
+				ConflictingParam: otherParam,
+			}
+		}
+
+		if shadows(routes, other, route) {
+			return &RouteConflictError{Kind: ConflictUnreachable, Route: route.info(), Conflicting: other.info()}
+		}
+
+		if shadows(routes, route, other) {
+			return &RouteConflictError{Kind: ConflictUnreachable, Route: other.info(), Conflicting: route.info()}
+		}
+
+		return nil
+	}
+
+	if route.Method == anyMethod && other.Method != anyMethod && other.isWildcard() && other.coversFrom(route, 0) {
+		return &RouteConflictError{Kind: ConflictWildcardShadow, Route: route.info(), Conflicting: other.info()}
+	}
+
+	if other.Method == anyMethod && route.Method != anyMethod && route.isWildcard() && route.coversFrom(other, 0) {
+		return &RouteConflictError{Kind: ConflictWildcardShadow, Route: other.info(), Conflicting: route.info()}
+	}
+
+	return nil
+}
+
+// shadows reports whether a has a higher priority than b and matches every
+// path b does, making b unreachable. Both routes must have the same method.
+func shadows[T RequestContext](routes []*Route[T], a, b *Route[T]) bool {
+	i := 0
+	for i < len(a.parts) && i < len(b.parts) && a.shape(i) == b.shape(i) {
+		i++
+	}
+
+	if i == len(a.parts) || i == len(b.parts) {
+		return false
+	}
+
+	aParam, bParam := a.params[i], b.params[i]
+	if aParam == nil || bParam == nil || !aParam.Covers(bParam) {
+		return false
+	}
+
+	switch {
+	case bParam.Key == radical.UnconstrainedKey:
+	case aParam.Key == radical.UnconstrainedKey:
+		return false
+	case aParam.LiteralLen() != bParam.LiteralLen():
+		if aParam.LiteralLen() < bParam.LiteralLen() {
+			return false
+		}
+	case firstWithShape(routes, a, i) > firstWithShape(routes, b, i):
+		return false
+	}
+
+	return a.coversFrom(b, i+1)
+}
+
+// firstWithShape returns the index of the first enabled route registered with
+// the same host, method, and shape as route up to and including segment i. It
+// determines the order params are tried in when searching the route tree.
+func firstWithShape[T RequestContext](routes []*Route[T], route *Route[T], i int) int {
+	for j, other := range routes {
+		if !other.disabled.Load() && other.host == route.host && other.Method == route.Method && len(other.parts) > i && other.sharesShape(route, i+1) {
+			return j
+		}
+	}
+
+	return -1
+}
+
+// shape returns the segment at i with param names removed, so that segments
+// matching the same paths in the same way have the same shape.
+func (r *Route[C]) shape(i int) string {
+	switch {
+	case r.params[i] != nil:
+		return r.params[i].Key
+	case strings.HasPrefix(r.parts[i], "*"):
+		return "*"
+	default:
+		return radical.LiteralKey(r.parts[i])
+	}
+}
+
+// sharesShape reports whether the first n segments of r and other have the
+// same shape.
+func (r *Route[C]) sharesShape(other *Route[C], n int) bool {
+	for i := 0; i < n; i++ {
+		if r.shape(i) != other.shape(i) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mismatchedParam returns the first param of r that is named differently than
+// the param at the same position of other, while every preceding segment has
+// the same shape.
+func (r *Route[C]) mismatchedParam(other *Route[C]) (string, string, bool) {
+	for i := 0; i < len(r.parts) && i < len(other.parts); i++ {
+		if r.shape(i) != other.shape(i) {
+			return "", "", false
+		}
+
+		if r.params[i] != nil {
+			for j, name := range r.params[i].Names {
+				if otherName := other.params[i].Names[j]; name != otherName {
+					return name, otherName, true
+				}
+			}
+		} else if strings.HasPrefix(r.parts[i], "*") && r.parts[i] != other.parts[i] {
+			return r.parts[i][1:], other.parts[i][1:], true
+		}
+	}
+
+	return "", "", false
+}
+
+// coversFrom reports whether r matches every path other matches, comparing
+// the segments starting at start.
+func (r *Route[C]) coversFrom(other *Route[C], start int) bool {
+	for i := start; i < len(r.parts); i++ {
+		if strings.HasPrefix(r.parts[i], "*") {
+			return i < len(other.parts)
+		}
+
+		if i >= len(other.parts) || strings.HasPrefix(other.parts[i], "*") {
+			return false
+		}
+
+		switch {
+		case r.params[i] == nil:
+			if other.params[i] != nil || r.parts[i] != other.parts[i] {
+				return false
+			}
+		case other.params[i] == nil:
+			if !r.params[i].Matches(other.parts[i]) {
+				return false
+			}
+		default:
+			if !r.params[i].Covers(other.params[i]) {
+				return false
+			}
+		}
+	}
+
+	return len(r.parts) == len(other.parts)
+}
+
+// describeRoute returns the method, path, and registration location of a
+// route for use in error messages. Mounts are described with the method MOUNT.
+func describeRoute(route RouteInfo) string {
+	return fmt.Sprintf("%s %s%s (%s:%d)", route.displayMethod(), route.Host, route.Path, relativeFile(route.File), route.Line)
+}
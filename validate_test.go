@@ -0,0 +1,178 @@
+package fernet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Validate(t *testing.T) {
+	handler := func(context.Context, *RootRequestContext) {}
+
+	tests := map[string]struct {
+		register func(r *Router[*RootRequestContext])
+		want     []ConflictKind
+	}{
+		"no conflicts": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/users/:id", handler)
+				r.Get("/users/:id/posts", handler)
+				r.Post("/users/:id", handler)
+				r.Get("/users/new", handler)
+				r.Get("/items/:id<int>", handler)
+				r.Get("/items/:slug", handler)
+				r.Get("/files/*path", handler)
+				r.Mount("/debug", http.NotFoundHandler())
+			},
+		},
+		"duplicate": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/users/:id", handler)
+				r.Get("/users/:user_id", handler)
+			},
+			want: []ConflictKind{ConflictDuplicate},
+		},
+		"matchers": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Post("/events", handler).ContentType("application/json")
+				r.Post("/events", handler).ContentType("application/protobuf")
+				r.Post("/events", handler)
+			},
+		},
+		"unreachable matchers": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Post("/events", handler)
+				r.Post("/events", handler).ContentType("application/json")
+			},
+			want: []ConflictKind{ConflictUnreachable},
+		},
+		"duplicate wildcard": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/files/*path", handler)
+				r.Namespace("/files").Get("/*rest", handler)
+			},
+			want: []ConflictKind{ConflictDuplicate},
+		},
+		"duplicate across hosts": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/users", handler)
+				r.Host("api.example.com").Get("/users", handler)
+			},
+		},
+		"param name mismatch": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/users/:id", handler)
+				r.Get("/users/:user_id/posts", handler)
+			},
+			want: []ConflictKind{ConflictParamName},
+		},
+		"param name mismatch across methods": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/users/:id", handler)
+				r.Delete("/users/:user_id/posts", handler)
+			},
+		},
+		"wildcard shadowing mount": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Mount("/api/users", http.NotFoundHandler())
+				r.Get("/api/*path", handler)
+			},
+			want: []ConflictKind{ConflictWildcardShadow, ConflictWildcardShadow},
+		},
+		"unreachable by match all constraint": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/items/:slug<.+>", handler)
+				r.Get("/items/:id<int>", handler)
+				r.Get("/items/:name", handler)
+			},
+			want: []ConflictKind{ConflictUnreachable, ConflictUnreachable},
+		},
+		"unreachable by equivalent constraint": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/items/:id<int>/edit", handler)
+				r.Get("/items/:n<-?[0-9]+>/edit", handler)
+			},
+			want: []ConflictKind{ConflictUnreachable},
+		},
+		"reachable by registration order": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/items/:id<int>", handler)
+				r.Get("/items/:slug<.+>", handler)
+			},
+		},
+		"unreachable by earlier registered shape": {
+			register: func(r *Router[*RootRequestContext]) {
+				r.Get("/items/:slug<.+>/edit", handler)
+				r.Get("/items/:id<int>", handler)
+				r.Get("/items/:id<int>/edit", handler)
+			},
+			want: []ConflictKind{ConflictUnreachable},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := New(WithBasicRequestContext)
+			tc.register(router)
+
+			err := router.Validate()
+			if len(tc.want) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			require.True(t, ok)
+
+			kinds := make([]ConflictKind, 0, len(joined.Unwrap()))
+			for _, err := range joined.Unwrap() {
+				var conflict *RouteConflictError
+				require.ErrorAs(t, err, &conflict)
+				kinds = append(kinds, conflict.Kind)
+			}
+
+			require.Equal(t, tc.want, kinds)
+		})
+	}
+}
+
+func TestRouter_ValidateMessages(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	handler := func(context.Context, *RootRequestContext) {}
+
+	_, _, line, _ := runtime.Caller(0)
+	router.Get("/users/:id", handler)
+	router.Get("/users/:user_id", handler)
+	router.Get("/users/:name/posts", handler)
+	router.Get("/items/:slug<.+>", handler)
+	router.Get("/items/:id<int>", handler)
+	router.Mount("/api/users", http.NotFoundHandler())
+	router.Get("/api/*path", handler)
+
+	err := router.Validate()
+	require.Error(t, err)
+
+	var conflict *RouteConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, "/users/:user_id", conflict.Route.Path)
+	require.Equal(t, line+2, conflict.Route.Line)
+	require.Equal(t, "/users/:id", conflict.Conflicting.Path)
+	require.Equal(t, line+1, conflict.Conflicting.Line)
+
+	require.Equal(t, []string{
+		"GET /users/:user_id (validate_test.go:" + strconv.Itoa(line+2) + ") duplicates GET /users/:id (validate_test.go:" + strconv.Itoa(line+1) + ")",
+		`GET /users/:name/posts (validate_test.go:` + strconv.Itoa(line+3) + `) names param "name" but GET /users/:id (validate_test.go:` + strconv.Itoa(line+1) + `) names it "id" at the same position`,
+		`GET /users/:name/posts (validate_test.go:` + strconv.Itoa(line+3) + `) names param "name" but GET /users/:user_id (validate_test.go:` + strconv.Itoa(line+2) + `) names it "user_id" at the same position`,
+		"GET /items/:id<int> (validate_test.go:" + strconv.Itoa(line+5) + ") is unreachable because GET /items/:slug<.+> (validate_test.go:" + strconv.Itoa(line+4) + ") matches every request it does",
+		"MOUNT /api/users (validate_test.go:" + strconv.Itoa(line+6) + ") is shadowed for GET requests by wildcard route GET /api/*path (validate_test.go:" + strconv.Itoa(line+7) + ")",
+		"MOUNT /api/users/* (validate_test.go:" + strconv.Itoa(line+6) + ") is shadowed for GET requests by wildcard route GET /api/*path (validate_test.go:" + strconv.Itoa(line+7) + ")",
+	}, strings.Split(err.Error(), "\n"))
+}
@@ -0,0 +1,157 @@
+package fernet
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// VersionParam is the param the version is captured in when a versioned
+	// route is requested using a version prefix like `/v2/users`.
+	VersionParam = "api_version"
+	// VersionHeader is the response header set to the API version of the
+	// route that handled a versioned request.
+	VersionHeader = "API-Version"
+)
+
+type (
+	// VersionedGroup registers routes for multiple versions of an API. Each
+	// route is registered for requests with a version prefix like
+	// `/v2/users`, and for requests to `/users` with a vendor media type in
+	// their Accept header like `application/vnd.example.v2+json`.
+	//
+	// Requests are handled by the route with the newest version that's not
+	// newer than the requested version, so routes that didn't change in a
+	// version only need to be registered for the version that introduced
+	// them. Requests to `/users` that don't specify a version are handled by
+	// the newest version of the route.
+	VersionedGroup[T RequestContext] struct {
+		parent Registerable[T]
+		vendor string
+	}
+
+	// versionRegistrar registers the routes of a single version of a
+	// VersionedGroup.
+	versionRegistrar[T RequestContext] struct {
+		group   *VersionedGroup[T]
+		version int
+	}
+)
+
+var _ Registerable[*RootRequestContext] = (*versionRegistrar[*RootRequestContext])(nil)
+
+// Versioned returns a VersionedGroup that registers versioned routes with
+// the router. vendor is the vendor name used in the media types of the Accept
+// header, e.g. `example` for `application/vnd.example.v2+json`.
+func (r *Router[T]) Versioned(vendor string) *VersionedGroup[T] {
+	return &VersionedGroup[T]{parent: r, vendor: vendor}
+}
+
+// Versioned returns a VersionedGroup that registers versioned routes with
+// the group. See Router.Versioned.
+func (g *Group[T]) Versioned(vendor string) *VersionedGroup[T] {
+	return &VersionedGroup[T]{parent: g, vendor: vendor}
+}
+
+// Version returns a group that registers routes for the given version, which
+// must be greater than 0. The response of each request handled by the group's
+// routes has its VersionHeader set to version.
+func (v *VersionedGroup[T]) Version(version int) *Group[T] {
+	if version < 1 {
+		panic(fmt.Sprintf("API version must be greater than 0, got %d", version))
+	}
+
+	return NewGroup[T](&versionRegistrar[T]{group: v, version: version}, "")
+}
+
+// RawMatch implements the Registerable interface and registers a route for
+// the version prefixed path, along with an alias for the unprefixed path that
+// is selected using the Accept header. The prefixed route is returned and
+// changes made to it are applied to the alias.
+func (v *versionRegistrar[T]) RawMatch(method string, path string, fn Handler[T]) *Route[T] {
+	label := strconv.Itoa(v.version)
+	handler := func(ctx context.Context, rctx T) {
+		rctx.Response().Header().Set(VersionHeader, label)
+		fn(ctx, rctx)
+	}
+
+	segment := "v:" + VersionParam + "<int>"
+	route := v.group.parent.RawMatch(method, joinURL("/"+segment, path), handler)
+	alias := v.group.parent.RawMatch(method, path, handler)
+
+	index := 0
+	for i, part := range route.parts {
+		if part == segment {
+			index = i
+			break
+		}
+	}
+
+	route.version = v.version
+	route.requestedVersion = func(req *http.Request, reqParts []string) (int, bool) {
+		version, err := strconv.Atoi(strings.TrimPrefix(reqParts[index], "v"))
+		return version, err == nil
+	}
+
+	alias.version = v.version
+	alias.requestedVersion = func(req *http.Request, reqParts []string) (int, bool) {
+		return acceptedVersion(req.Header.Values("Accept"), v.group.vendor)
+	}
+
+	route.aliases = append(route.aliases, alias)
+
+	return route
+}
+
+// ensureMutable implements the mutable interface.
+func (v *versionRegistrar[T]) ensureMutable(action string) {
+	ensureMutable(v.group.parent, action)
+}
+
+// matchesVersion reports whether the route can handle a request for the
+// version the request asks for. Unversioned routes and requests that don't
+// ask for a version match any version.
+func (r *Route[C]) matchesVersion(req *http.Request, reqParts []string) bool {
+	if r.requestedVersion == nil {
+		return true
+	}
+
+	requested, ok := r.requestedVersion(req, reqParts)
+	if !ok {
+		requested = math.MaxInt
+	}
+
+	return r.version <= requested
+}
+
+// acceptedVersion returns the version of the first vendor media type in the
+// Accept header values, like `application/vnd.example.v2+json`.
+func acceptedVersion(accept []string, vendor string) (int, bool) {
+	prefix := "vnd." + strings.ToLower(vendor) + ".v"
+
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			_, subtype, _ := strings.Cut(mediaType, "/")
+			if !strings.HasPrefix(subtype, prefix) {
+				continue
+			}
+
+			subtype, _, _ = strings.Cut(strings.TrimPrefix(subtype, prefix), "+")
+			if version, err := strconv.Atoi(subtype); err == nil {
+				return version, true
+			}
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,207 @@
+package fernet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Versioned(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	respond := func(body string) Handler[*RootRequestContext] {
+		return func(ctx context.Context, r *RootRequestContext) {
+			_, _ = r.Response().Write([]byte(body + r.Param("id")))
+		}
+	}
+
+	api := router.Namespace("/api").Versioned("ours")
+
+	v1 := api.Version(1)
+	v1.Get("/users/:id", respond("v1 user ")).Name("user")
+	v1.Get("/posts", respond("v1 posts"))
+
+	v2 := api.Version(2)
+	v2.Get("/users/:id", respond("v2 user "))
+
+	v4 := api.Version(4)
+	v4.Get("/posts", respond("v4 posts"))
+
+	router.Get("/api/health", respond("ok"))
+
+	tests := map[string]struct {
+		path        string
+		accept      string
+		wantStatus  int
+		wantBody    string
+		wantVersion string
+	}{
+		"prefix": {
+			path:        "/api/v1/users/5",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v1 user 5",
+			wantVersion: "1",
+		},
+		"prefix newer version": {
+			path:        "/api/v2/users/5",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v2 user 5",
+			wantVersion: "2",
+		},
+		"prefix unknown version falls back": {
+			path:        "/api/v3/users/5",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v2 user 5",
+			wantVersion: "2",
+		},
+		"prefix unchanged route falls back": {
+			path:        "/api/v3/posts",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v1 posts",
+			wantVersion: "1",
+		},
+		"prefix before first version": {
+			path:       "/api/v0/posts",
+			wantStatus: http.StatusNotFound,
+		},
+		"header": {
+			path:        "/api/users/5",
+			accept:      "application/vnd.ours.v1+json",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v1 user 5",
+			wantVersion: "1",
+		},
+		"header unknown version falls back": {
+			path:        "/api/posts",
+			accept:      "text/html, application/vnd.ours.v3+json",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v1 posts",
+			wantVersion: "1",
+		},
+		"header newest version": {
+			path:        "/api/posts",
+			accept:      "application/vnd.ours.v9+json",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v4 posts",
+			wantVersion: "4",
+		},
+		"header other vendor uses newest version": {
+			path:        "/api/users/5",
+			accept:      "application/vnd.theirs.v1+json",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v2 user 5",
+			wantVersion: "2",
+		},
+		"no version uses newest version": {
+			path:        "/api/posts",
+			wantStatus:  http.StatusOK,
+			wantBody:    "v4 posts",
+			wantVersion: "4",
+		},
+		"unversioned route": {
+			path:       "/api/health",
+			accept:     "application/vnd.ours.v1+json",
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.wantStatus, res.Code)
+			require.Equal(t, tc.wantBody, res.Body.String())
+			require.Equal(t, tc.wantVersion, res.Header().Get(VersionHeader))
+		})
+	}
+
+	url, err := router.URL("user", VersionParam, "2", "id", "5")
+	require.NoError(t, err)
+	require.Equal(t, "/api/v2/users/5", url)
+	require.NoError(t, router.Validate())
+}
+
+func TestRouter_VersionedGroupMiddleware(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	v2 := router.Versioned("ours").Version(2).Meta("scope", "v2")
+	v2.Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+		scope, _ := Meta[string](r, "scope")
+		r.Response().Header().Set("x-scope", scope)
+		next(ctx, r)
+	})
+	v2.Get("/users", func(context.Context, *RootRequestContext) {})
+
+	tests := map[string]struct {
+		path   string
+		accept string
+	}{
+		"path version":   {path: "/v2/users"},
+		"accept version": {path: "/users", accept: "application/vnd.ours.v2+json"},
+	}
+
+	for testName, tc := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, http.StatusOK, res.Code)
+			require.Equal(t, "v2", res.Header().Get("x-scope"))
+		})
+	}
+}
+
+func TestRouter_VersionedAliases(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	v1 := router.Versioned("ours").Version(1)
+
+	var calls int
+	route := v1.Get("/users", func(context.Context, *RootRequestContext) {}).
+		Use(func(ctx context.Context, r *RootRequestContext, next Handler[*RootRequestContext]) {
+			calls++
+			next(ctx, r)
+		}).
+		Header("X-Token", "")
+
+	for _, path := range []string{"/v1/users", "/users"} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusNotFound, res.Code)
+
+		res = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Token", "secret")
+		router.ServeHTTP(res, req)
+		require.Equal(t, http.StatusOK, res.Code)
+	}
+
+	require.Equal(t, 2, calls)
+
+	router.Update(func() {
+		route.Disable()
+	})
+
+	for _, path := range []string{"/v1/users", "/users"} {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Token", "secret")
+		router.ServeHTTP(res, req)
+		require.Equal(t, http.StatusNotFound, res.Code)
+	}
+
+	require.Panics(t, func() {
+		router.Versioned("ours").Version(0)
+	})
+}
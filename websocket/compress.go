@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// deflateTail is appended to compressed messages before they're inflated. The
+// first four bytes restore the tail removed by the sender and the rest is an
+// empty final block, so the reader returns io.EOF at the end of the message.
+const deflateTail = "\x00\x00\xff\xff\x01\x00\x00\xff\xff"
+
+// flateWriterPool reuses flate writers, which are expensive to allocate, across
+// messages. Contexts aren't shared between messages, so writers are reset
+// before every use.
+var flateWriterPool sync.Pool
+
+// acquireFlateWriter returns a flate writer from the pool that writes to w.
+func acquireFlateWriter(w io.Writer) *flate.Writer {
+	if fw, ok := flateWriterPool.Get().(*flate.Writer); ok {
+		fw.Reset(w)
+		return fw
+	}
+
+	fw, _ := flate.NewWriter(w, flate.BestSpeed)
+	return fw
+}
+
+// releaseFlateWriter returns fw to the pool.
+func releaseFlateWriter(fw *flate.Writer) {
+	flateWriterPool.Put(fw)
+}
+
+// decompress inflates the payload of a compressed message, returning
+// ErrMessageTooBig if the inflated message is larger than limit.
+func decompress(payload []byte, limit int64) ([]byte, error) {
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(payload), strings.NewReader(deflateTail)))
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, ErrMessageTooBig
+	}
+
+	return data, nil
+}
+
+// offersDeflate reports whether the client offered the permessage-deflate
+// extension with parameters the server can accept. Go's flate package always
+// compresses using a 32KB window, so offers that limit the server's window are
+// declined.
+func offersDeflate(req *http.Request) bool {
+	for _, value := range req.Header.Values("Sec-WebSocket-Extensions") {
+		for _, extension := range strings.Split(value, ",") {
+			params := strings.Split(extension, ";")
+			if strings.TrimSpace(params[0]) != "permessage-deflate" {
+				continue
+			}
+
+			if acceptableDeflateParams(params[1:]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func acceptableDeflateParams(params []string) bool {
+	for _, param := range params {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch strings.TrimSpace(name) {
+		case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+		case "server_max_window_bits":
+			if value != "15" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
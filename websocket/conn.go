@@ -0,0 +1,542 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+const (
+	// TextMessage is a message containing UTF-8 encoded text.
+	TextMessage MessageType = MessageType(opText)
+	// BinaryMessage is a message containing binary data.
+	BinaryMessage MessageType = MessageType(opBinary)
+)
+
+// StatusCode is the status code sent in a close frame, explaining why the
+// connection was closed.
+type StatusCode int
+
+// Status codes defined by RFC 6455.
+const (
+	StatusNormalClosure      StatusCode = 1000
+	StatusGoingAway          StatusCode = 1001
+	StatusProtocolError      StatusCode = 1002
+	StatusUnsupportedData    StatusCode = 1003
+	StatusNoStatus           StatusCode = 1005
+	StatusAbnormalClosure    StatusCode = 1006
+	StatusInvalidPayload     StatusCode = 1007
+	StatusPolicyViolation    StatusCode = 1008
+	StatusMessageTooBig      StatusCode = 1009
+	StatusMandatoryExtension StatusCode = 1010
+	StatusInternalError      StatusCode = 1011
+)
+
+var (
+	// ErrProtocol is returned when the peer violates the WebSocket protocol.
+	// The connection is closed with StatusProtocolError.
+	ErrProtocol = errors.New("websocket: protocol error")
+	// ErrMessageTooBig is returned when the peer sends a message larger than
+	// the read limit. The connection is closed with StatusMessageTooBig.
+	ErrMessageTooBig = errors.New("websocket: message too big")
+	// ErrInvalidUTF8 is returned when the peer sends a text message that
+	// isn't valid UTF-8. The connection is closed with StatusInvalidPayload.
+	ErrInvalidUTF8 = errors.New("websocket: text message is not valid UTF-8")
+	// ErrClosed is returned when writing to a connection after a close frame
+	// has been sent.
+	ErrClosed = errors.New("websocket: connection closed")
+)
+
+// CloseError is returned by ReadMessage when the peer closes the connection.
+type CloseError struct {
+	Code   StatusCode
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: connection closed with status %d", e.Code)
+	}
+
+	return fmt.Sprintf("websocket: connection closed with status %d: %s", e.Code, e.Reason)
+}
+
+// Conn is a WebSocket connection. Messages can be read by one goroutine at a
+// time while other goroutines write to the connection.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	bw          *bufio.Writer
+	client      bool
+	subprotocol string
+	compression bool
+	readLimit   int64
+	onPong      func(data []byte)
+
+	// readErr is returned by every read after the first failed read.
+	readErr error
+
+	// messageMu is held while a message is being written, so the frames of
+	// concurrently written messages aren't interleaved. Control frames can be
+	// sent between the frames of a message, so they only hold writeMu.
+	messageMu sync.Mutex
+	writeMu   sync.Mutex
+	closeSent bool
+	frame     []byte
+}
+
+// newConn returns a Conn that reads from br and writes to bw. Client
+// connections mask the frames they write and expect unmasked frames from the
+// server.
+func newConn(conn net.Conn, br *bufio.Reader, bw *bufio.Writer, client bool, readLimit int64) *Conn {
+	return &Conn{conn: conn, br: br, bw: bw, client: client, readLimit: readLimit}
+}
+
+// Subprotocol returns the subprotocol selected during the handshake.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the deadline for reading from the connection. Reads
+// after the deadline fail and the connection can no longer be read from.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for writing to the connection.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetPongHandler sets a function that's called with the payload of each pong
+// received while reading messages, e.g. to extend the read deadline.
+func (c *Conn) SetPongHandler(fn func(data []byte)) {
+	c.onPong = fn
+}
+
+// ReadMessage reads the next data message from the peer. Fragmented messages
+// are reassembled and compressed messages are decompressed. Pings are
+// answered with pongs while waiting for a message.
+//
+// When the peer closes the connection, the close is acknowledged and a
+// *CloseError is returned. If the peer violates the protocol, the connection
+// is closed and the error is returned. Once a read fails, every subsequent
+// read returns the same error.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+
+	typ, data, err := c.readMessage()
+	if err != nil {
+		c.readErr = err
+	}
+
+	return typ, data, err
+}
+
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		typ        MessageType
+		compressed bool
+		message    []byte
+	)
+
+	for {
+		h, err := readFrameHeader(c.br)
+		if err != nil {
+			if errors.Is(err, ErrProtocol) {
+				return 0, nil, c.fail(StatusProtocolError, err)
+			}
+
+			return 0, nil, err
+		}
+
+		if h.rsv23 {
+			return 0, nil, c.fail(StatusProtocolError, fmt.Errorf("%w: unexpected reserved bits", ErrProtocol))
+		}
+
+		if h.masked == c.client {
+			return 0, nil, c.fail(StatusProtocolError, fmt.Errorf("%w: invalid frame masking", ErrProtocol))
+		}
+
+		if h.isControl() {
+			if err := c.readControl(h); err != nil {
+				return 0, nil, err
+			}
+
+			continue
+		}
+
+		switch h.opcode {
+		case opContinuation:
+			if typ == 0 {
+				return 0, nil, c.fail(StatusProtocolError, fmt.Errorf("%w: unexpected continuation frame", ErrProtocol))
+			}
+
+			if h.rsv1 {
+				return 0, nil, c.fail(StatusProtocolError, fmt.Errorf("%w: unexpected reserved bits", ErrProtocol))
+			}
+		case opText, opBinary:
+			if typ != 0 {
+				return 0, nil, c.fail(StatusProtocolError, fmt.Errorf("%w: expected continuation frame", ErrProtocol))
+			}
+
+			if h.rsv1 && !c.compression {
+				return 0, nil, c.fail(StatusProtocolError, fmt.Errorf("%w: unexpected reserved bits", ErrProtocol))
+			}
+
+			typ = MessageType(h.opcode)
+			compressed = h.rsv1
+		default:
+			return 0, nil, c.fail(StatusProtocolError, fmt.Errorf("%w: unknown opcode %d", ErrProtocol, h.opcode))
+		}
+
+		if int64(len(message))+h.length > c.readLimit {
+			return 0, nil, c.fail(StatusMessageTooBig, ErrMessageTooBig)
+		}
+
+		message, err = c.readPayload(message, h)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if h.fin {
+			break
+		}
+	}
+
+	if compressed {
+		var err error
+		message, err = decompress(message, c.readLimit)
+
+		switch {
+		case errors.Is(err, ErrMessageTooBig):
+			return 0, nil, c.fail(StatusMessageTooBig, err)
+		case err != nil:
+			return 0, nil, c.fail(StatusInvalidPayload, fmt.Errorf("%w: invalid compressed message", ErrProtocol))
+		}
+	}
+
+	if typ == TextMessage && !utf8.Valid(message) {
+		return 0, nil, c.fail(StatusInvalidPayload, ErrInvalidUTF8)
+	}
+
+	if message == nil {
+		message = []byte{}
+	}
+
+	return typ, message, nil
+}
+
+// readControl handles a control frame received while reading a message.
+func (c *Conn) readControl(h frameHeader) error {
+	if !h.fin || h.length > maxControlPayload {
+		return c.fail(StatusProtocolError, fmt.Errorf("%w: invalid control frame", ErrProtocol))
+	}
+
+	if h.rsv1 {
+		return c.fail(StatusProtocolError, fmt.Errorf("%w: unexpected reserved bits", ErrProtocol))
+	}
+
+	payload, err := c.readPayload(nil, h)
+	if err != nil {
+		return err
+	}
+
+	switch h.opcode {
+	case opPing:
+		if err := c.writeFrame(true, false, opPong, payload); err != nil && !errors.Is(err, ErrClosed) {
+			return err
+		}
+	case opPong:
+		if c.onPong != nil {
+			c.onPong(payload)
+		}
+	case opClose:
+		code := StatusNoStatus
+		reason := ""
+
+		switch {
+		case len(payload) == 1:
+			return c.fail(StatusProtocolError, fmt.Errorf("%w: invalid close frame", ErrProtocol))
+		case len(payload) >= 2:
+			code = StatusCode(binary.BigEndian.Uint16(payload))
+			reason = string(payload[2:])
+
+			if !validCloseCode(code) || !utf8.ValidString(reason) {
+				return c.fail(StatusProtocolError, fmt.Errorf("%w: invalid close frame", ErrProtocol))
+			}
+		}
+
+		// Acknowledge the close by echoing the status code.
+		_ = c.writeClose(code, "")
+
+		return &CloseError{Code: code, Reason: reason}
+	default:
+		return c.fail(StatusProtocolError, fmt.Errorf("%w: unknown opcode %d", ErrProtocol, h.opcode))
+	}
+
+	return nil
+}
+
+// readPayload appends the unmasked payload of the frame to b.
+func (c *Conn) readPayload(b []byte, h frameHeader) ([]byte, error) {
+	start := len(b)
+	b = append(b, make([]byte, h.length)...)
+
+	if _, err := io.ReadFull(c.br, b[start:]); err != nil {
+		return nil, err
+	}
+
+	if h.masked {
+		maskBytes(h.mask, b[start:])
+	}
+
+	return b, nil
+}
+
+// WriteMessage writes a data message to the peer in a single frame.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	w, err := c.nextWriter(typ)
+	if err != nil {
+		return err
+	}
+
+	return w.close(data)
+}
+
+// NextWriter returns a writer for the next data message. Each call to Write
+// sends a fragment of the message, and Close sends the final fragment. Other
+// messages can't be written until the writer is closed.
+func (c *Conn) NextWriter(typ MessageType) (io.WriteCloser, error) {
+	return c.nextWriter(typ)
+}
+
+func (c *Conn) nextWriter(typ MessageType) (*messageWriter, error) {
+	if typ != TextMessage && typ != BinaryMessage {
+		return nil, fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+
+	c.messageMu.Lock()
+
+	w := &messageWriter{conn: c, opcode: byte(typ), compress: c.compression}
+	if w.compress {
+		w.flate = acquireFlateWriter(&w.buf)
+	}
+
+	return w, nil
+}
+
+// Ping sends a ping to the peer, which answers with a pong containing the same
+// data. Pongs are passed to the function set by SetPongHandler.
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return fmt.Errorf("websocket: ping payload must not be larger than %d bytes", maxControlPayload)
+	}
+
+	return c.writeFrame(true, false, opPing, data)
+}
+
+// Close sends a close frame with the status code and reason to the peer, if
+// one hasn't been sent already, and closes the underlying connection.
+func (c *Conn) Close(code StatusCode, reason string) error {
+	err := c.writeClose(code, reason)
+	if errors.Is(err, ErrClosed) {
+		err = nil
+	}
+
+	if closeErr := c.conn.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// fail closes the connection with code after the peer sent invalid data, and
+// returns err.
+func (c *Conn) fail(code StatusCode, err error) error {
+	_ = c.Close(code, err.Error())
+	return err
+}
+
+// writeClose sends a close frame with the status code and reason.
+// StatusNoStatus sends a close frame without a status code.
+func (c *Conn) writeClose(code StatusCode, reason string) error {
+	if code == StatusNoStatus {
+		return c.writeFrame(true, false, opClose, nil)
+	}
+
+	// Reasons are truncated to fit in a control frame without splitting a
+	// character.
+	for len(reason) > maxControlPayload-2 {
+		_, size := utf8.DecodeLastRuneInString(reason)
+		reason = reason[:len(reason)-size]
+	}
+
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+
+	return c.writeFrame(true, false, opClose, payload)
+}
+
+// writeFrame writes a single frame to the peer, masking it if the connection
+// is a client.
+func (c *Conn) writeFrame(fin bool, rsv1 bool, opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+
+	if opcode == opClose {
+		c.closeSent = true
+	}
+
+	var mask *[4]byte
+	if c.client {
+		mask = new([4]byte)
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+	}
+
+	c.frame = appendFrameHeader(c.frame[:0], fin, rsv1, opcode, len(payload), mask)
+	start := len(c.frame)
+	c.frame = append(c.frame, payload...)
+
+	if mask != nil {
+		maskBytes(*mask, c.frame[start:])
+	}
+
+	if _, err := c.bw.Write(c.frame); err != nil {
+		return err
+	}
+
+	return c.bw.Flush()
+}
+
+// validCloseCode reports whether code can be sent in a close frame.
+func validCloseCode(code StatusCode) bool {
+	switch {
+	case code >= 1000 && code <= 1014:
+		return code != 1004 && code != StatusNoStatus && code != StatusAbnormalClosure
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+// messageWriter writes a message to the connection, sending a frame for each
+// call to Write.
+type messageWriter struct {
+	conn     *Conn
+	opcode   byte
+	compress bool
+	closed   bool
+	flate    *flate.Writer
+	// buf holds the compressed output that hasn't been sent yet. The last
+	// four bytes of the compressed message are removed before it's sent, so
+	// they're held back until the next write.
+	buf bytes.Buffer
+}
+
+// Write sends p as a fragment of the message.
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !w.compress {
+		if err := w.writeFrame(false, p); err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	if _, err := w.flate.Write(p); err != nil {
+		return 0, err
+	}
+
+	if err := w.flate.Flush(); err != nil {
+		return 0, err
+	}
+
+	ready := w.buf.Len() - 4
+	if err := w.writeFrame(false, w.buf.Bytes()[:ready]); err != nil {
+		return 0, err
+	}
+
+	w.buf.Next(ready)
+
+	return len(p), nil
+}
+
+// Close sends the final fragment of the message and allows the next message
+// to be written.
+func (w *messageWriter) Close() error {
+	return w.close(nil)
+}
+
+// close sends p as the final fragment of the message.
+func (w *messageWriter) close(p []byte) error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+	defer w.conn.messageMu.Unlock()
+
+	if !w.compress {
+		return w.writeFrame(true, p)
+	}
+
+	defer releaseFlateWriter(w.flate)
+
+	// The compressed output of previous writes has already been flushed, so
+	// it only needs to be flushed again if there's more data, or if there
+	// was no data at all since messages need at least an empty block.
+	if len(p) > 0 || w.buf.Len() == 0 {
+		if _, err := w.flate.Write(p); err != nil {
+			return err
+		}
+
+		if err := w.flate.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return w.writeFrame(true, w.buf.Bytes()[:w.buf.Len()-4])
+}
+
+// writeFrame sends a fragment of the message. The first fragment is sent with
+// the message's opcode, and the following fragments as continuations.
+func (w *messageWriter) writeFrame(fin bool, payload []byte) error {
+	err := w.conn.writeFrame(fin, w.compress && w.opcode != opContinuation, w.opcode, payload)
+	w.opcode = opContinuation
+
+	return err
+}
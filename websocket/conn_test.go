@@ -0,0 +1,204 @@
+package websocket
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConn_Fragmentation(t *testing.T) {
+	server := newServer(t, func(ctx context.Context, conn *Conn) {
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			w, err := conn.NextWriter(typ)
+			if err != nil {
+				return
+			}
+
+			for _, part := range strings.SplitAfter(string(data), " ") {
+				_, _ = w.Write([]byte(part))
+			}
+
+			_ = w.Close()
+		}
+	})
+
+	client, _ := dial(t, server, nil)
+	defer client.Close(StatusNormalClosure, "")
+
+	var pongs []string
+	client.SetPongHandler(func(data []byte) {
+		pongs = append(pongs, string(data))
+	})
+
+	// Control frames can be sent between the fragments of a message.
+	w, err := client.NextWriter(TextMessage)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("one "))
+	require.NoError(t, client.Ping([]byte("ping")))
+	_, _ = w.Write([]byte("two "))
+	_, _ = w.Write([]byte("three"))
+	require.NoError(t, w.Close())
+
+	typ, data, err := client.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, TextMessage, typ)
+	require.Equal(t, "one two three", string(data))
+	require.Equal(t, []string{"ping"}, pongs)
+
+	_, err = w.Write([]byte("closed"))
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestConn_Close(t *testing.T) {
+	t.Run("server", func(t *testing.T) {
+		server := newServer(t, func(ctx context.Context, conn *Conn) {
+			_ = conn.Close(StatusGoingAway, "shutting down")
+		})
+
+		client, _ := dial(t, server, nil)
+		defer client.Close(StatusNormalClosure, "")
+
+		_, _, err := client.ReadMessage()
+		require.Equal(t, &CloseError{Code: StatusGoingAway, Reason: "shutting down"}, err)
+
+		_, _, err = client.ReadMessage()
+		require.Equal(t, &CloseError{Code: StatusGoingAway, Reason: "shutting down"}, err)
+		require.ErrorIs(t, client.WriteMessage(TextMessage, []byte("late")), ErrClosed)
+	})
+
+	t.Run("client", func(t *testing.T) {
+		errs := make(chan error, 1)
+		server := newServer(t, func(ctx context.Context, conn *Conn) {
+			_, _, err := conn.ReadMessage()
+			errs <- err
+		})
+
+		client, _ := dial(t, server, nil)
+		require.NoError(t, client.writeClose(StatusNormalClosure, "bye"))
+
+		// The server acknowledges the close with the same status code.
+		_, _, err := client.ReadMessage()
+		require.Equal(t, &CloseError{Code: StatusNormalClosure}, err)
+
+		select {
+		case err := <-errs:
+			require.Equal(t, &CloseError{Code: StatusNormalClosure, Reason: "bye"}, err)
+		case <-time.After(time.Second):
+			require.Fail(t, "server did not receive the close frame")
+		}
+
+		require.NoError(t, client.Close(StatusNormalClosure, ""))
+	})
+}
+
+func TestConn_ProtocolErrors(t *testing.T) {
+	testCases := map[string]struct {
+		opts  []Option
+		frame []byte
+		code  StatusCode
+		err   error
+	}{
+		"unmasked frame": {
+			frame: append(appendFrameHeader(nil, true, false, opText, 2, nil), "hi"...),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"unknown opcode": {
+			frame: clientFrame(0x83, "hi"),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"reserved bits": {
+			frame: clientFrame(0xa1, "hi"),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"compressed without extension": {
+			frame: clientFrame(0xc1, "hi"),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"fragmented control frame": {
+			frame: clientFrame(0x09, "ping"),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"large control frame": {
+			frame: clientFrame(0x89, strings.Repeat("a", 126)),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"unexpected continuation": {
+			frame: clientFrame(0x80, "hi"),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"interleaved messages": {
+			frame: append(clientFrame(0x01, "one"), clientFrame(0x81, "two")...),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"invalid close frame": {
+			frame: clientFrame(0x88, "\x03\xed"),
+			code:  StatusProtocolError,
+			err:   ErrProtocol,
+		},
+		"invalid utf-8": {
+			frame: clientFrame(0x81, "\xff"),
+			code:  StatusInvalidPayload,
+			err:   ErrInvalidUTF8,
+		},
+		"message too big": {
+			opts:  []Option{WithReadLimit(4)},
+			frame: append(clientFrame(0x02, "abc"), clientFrame(0x80, "de")...),
+			code:  StatusMessageTooBig,
+			err:   ErrMessageTooBig,
+		},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			errs := make(chan error, 1)
+			server := newServer(t, func(ctx context.Context, conn *Conn) {
+				_, _, err := conn.ReadMessage()
+				errs <- err
+			}, tc.opts...)
+
+			client, _ := dial(t, server, nil)
+			defer client.Close(StatusNormalClosure, "")
+
+			_, err := client.bw.Write(tc.frame)
+			require.NoError(t, err)
+			require.NoError(t, client.bw.Flush())
+
+			_, _, err = client.ReadMessage()
+			var closeErr *CloseError
+			require.ErrorAs(t, err, &closeErr)
+			require.Equal(t, tc.code, closeErr.Code)
+
+			select {
+			case err := <-errs:
+				require.ErrorIs(t, err, tc.err)
+			case <-time.After(time.Second):
+				require.Fail(t, "server did not fail the connection")
+			}
+		})
+	}
+}
+
+// clientFrame returns a masked frame with the given first byte, which contains
+// the FIN bit, reserved bits, and opcode.
+func clientFrame(first byte, payload string) []byte {
+	frame := appendFrameHeader(nil, false, false, 0, len(payload), &[4]byte{})
+	frame[0] = first
+
+	return append(frame, payload...)
+}
@@ -0,0 +1,124 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Frame opcodes defined by RFC 6455.
+const (
+	opContinuation byte = 0x0
+	opText         byte = 0x1
+	opBinary       byte = 0x2
+	opClose        byte = 0x8
+	opPing         byte = 0x9
+	opPong         byte = 0xa
+)
+
+// maxControlPayload is the maximum payload size of control frames.
+const maxControlPayload = 125
+
+// frameHeader is the header of a single frame.
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	rsv23  bool
+	opcode byte
+	length int64
+	masked bool
+	mask   [4]byte
+}
+
+// isControl reports whether the frame is a close, ping, or pong frame.
+func (h frameHeader) isControl() bool {
+	return h.opcode&0x8 != 0
+}
+
+// readFrameHeader reads a frame header from r.
+func readFrameHeader(r io.Reader) (frameHeader, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:2]); err != nil {
+		return frameHeader{}, err
+	}
+
+	h := frameHeader{
+		fin:    b[0]&0x80 != 0,
+		rsv1:   b[0]&0x40 != 0,
+		rsv23:  b[0]&0x30 != 0,
+		opcode: b[0] & 0x0f,
+		masked: b[1]&0x80 != 0,
+		length: int64(b[1] & 0x7f),
+	}
+
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(r, b[:2]); err != nil {
+			return frameHeader{}, err
+		}
+
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(r, b[:8]); err != nil {
+			return frameHeader{}, err
+		}
+
+		length := binary.BigEndian.Uint64(b[:8])
+		if length > math.MaxInt64 {
+			return frameHeader{}, fmt.Errorf("%w: invalid frame length", ErrProtocol)
+		}
+
+		h.length = int64(length)
+	}
+
+	if h.masked {
+		if _, err := io.ReadFull(r, h.mask[:]); err != nil {
+			return frameHeader{}, err
+		}
+	}
+
+	return h, nil
+}
+
+// appendFrameHeader appends the encoded header of a frame to b. The frame is
+// masked with mask if it's not nil.
+func appendFrameHeader(b []byte, fin bool, rsv1 bool, opcode byte, length int, mask *[4]byte) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+
+	if rsv1 {
+		first |= 0x40
+	}
+
+	var maskBit byte
+	if mask != nil {
+		maskBit = 0x80
+	}
+
+	switch {
+	case length <= 125:
+		b = append(b, first, maskBit|byte(length))
+	case length <= math.MaxUint16:
+		b = append(b, first, maskBit|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(length))
+	default:
+		b = append(b, first, maskBit|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(length))
+	}
+
+	if mask != nil {
+		b = append(b, mask[:]...)
+	}
+
+	return b
+}
+
+// maskBytes masks or unmasks b in place using mask.
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameHeader(t *testing.T) {
+	testCases := map[string]struct {
+		length     int
+		masked     bool
+		headerSize int
+	}{
+		"empty":         {length: 0, headerSize: 2},
+		"7-bit length":  {length: 125, headerSize: 2},
+		"16-bit length": {length: 126, headerSize: 4},
+		"max 16-bit":    {length: 65535, headerSize: 4},
+		"64-bit length": {length: 65536, headerSize: 10},
+		"large 64-bit":  {length: 1 << 32, headerSize: 10},
+		"masked 7-bit":  {length: 5, masked: true, headerSize: 6},
+		"masked 16-bit": {length: 300, masked: true, headerSize: 8},
+		"masked 64-bit": {length: 70000, masked: true, headerSize: 14},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			var mask *[4]byte
+			if tc.masked {
+				mask = &[4]byte{1, 2, 3, 4}
+			}
+
+			b := appendFrameHeader(nil, true, true, opBinary, tc.length, mask)
+			require.Len(t, b, tc.headerSize)
+
+			h, err := readFrameHeader(bytes.NewReader(b))
+			require.NoError(t, err)
+			require.True(t, h.fin)
+			require.True(t, h.rsv1)
+			require.False(t, h.rsv23)
+			require.Equal(t, opBinary, h.opcode)
+			require.Equal(t, int64(tc.length), h.length)
+			require.Equal(t, tc.masked, h.masked)
+
+			if tc.masked {
+				require.Equal(t, *mask, h.mask)
+			}
+		})
+	}
+}
+
+func TestMaskBytes(t *testing.T) {
+	mask := [4]byte{0x37, 0xfa, 0x21, 0x3d}
+	b := []byte("Hello")
+
+	// The example from section 5.7 of RFC 6455.
+	maskBytes(mask, b)
+	require.Equal(t, []byte{0x7f, 0x9f, 0x4d, 0x51, 0x58}, b)
+
+	maskBytes(mask, b)
+	require.Equal(t, "Hello", string(b))
+}
@@ -0,0 +1,256 @@
+// Package websocket implements the WebSocket protocol defined in RFC 6455 for
+// fernet handlers, including the permessage-deflate extension defined in RFC
+// 7692.
+//
+// Connections are upgraded from inside a handler, so they run after the
+// router's middleware has authenticated the request or assigned it an ID.
+//
+//	router.Get("/ws", func(ctx context.Context, r *AppContext) {
+//		conn, err := websocket.Upgrade(r)
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close(websocket.StatusNormalClosure, "")
+//
+//		for {
+//			typ, data, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//
+//			_ = conn.WriteMessage(typ, data)
+//		}
+//	})
+package websocket
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/fernet"
+)
+
+// ErrBadHandshake is returned by Upgrade when the request isn't a valid
+// WebSocket handshake.
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// acceptGUID is appended to the Sec-WebSocket-Key of the request to compute
+// Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+type (
+	// Option configures Upgrade.
+	Option func(*options)
+
+	options struct {
+		subprotocols []string
+		compression  bool
+		readLimit    int64
+		checkOrigin  func(*http.Request) bool
+	}
+)
+
+// WithSubprotocols sets the subprotocols supported by the server, in order of
+// preference. The first one that the client also supports is selected and
+// returned by Conn.Subprotocol.
+func WithSubprotocols(protocols ...string) Option {
+	return func(o *options) {
+		o.subprotocols = protocols
+	}
+}
+
+// WithCompression compresses messages using the permessage-deflate extension
+// when the client supports it.
+func WithCompression() Option {
+	return func(o *options) {
+		o.compression = true
+	}
+}
+
+// WithReadLimit sets the maximum size of a message read from the client, after
+// it has been decompressed. Connections receiving larger messages are closed
+// with StatusMessageTooBig. It defaults to 32MB.
+func WithReadLimit(limit int64) Option {
+	return func(o *options) {
+		o.readLimit = limit
+	}
+}
+
+// WithCheckOrigin sets the function used to decide whether the Origin of the
+// request is allowed. By default requests are only allowed if they have no
+// Origin header or the host of their Origin matches their Host header.
+func WithCheckOrigin(fn func(req *http.Request) bool) Option {
+	return func(o *options) {
+		o.checkOrigin = fn
+	}
+}
+
+// Upgrade performs the WebSocket handshake for the request and hijacks its
+// connection. Headers already set on the response, like those set by
+// middleware, are included in the handshake response.
+//
+// If the request isn't a valid handshake, an error response is written and an
+// error wrapping ErrBadHandshake is returned. The returned Conn isn't tied to
+// the request and can outlive the handler.
+func Upgrade(rctx fernet.RequestContext, opts ...Option) (*Conn, error) {
+	o := options{readLimit: 32 << 20, checkOrigin: sameOrigin}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	req := rctx.Request()
+	res := rctx.Response()
+
+	if status, err := checkHandshake(req, o); err != nil {
+		if status == http.StatusUpgradeRequired {
+			res.Header().Set("Sec-WebSocket-Version", "13")
+		}
+
+		res.WriteHeader(status)
+		_, _ = res.Write([]byte(err.Error()))
+
+		return nil, err
+	}
+
+	subprotocol := selectSubprotocol(req, o.subprotocols)
+	compression := o.compression && offersDeflate(req)
+
+	netConn, brw, err := http.NewResponseController(res).Hijack()
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: could not hijack connection: %w", err)
+	}
+
+	// The server may have set deadlines for reading the request and writing
+	// the response, which don't apply to the WebSocket connection.
+	_ = netConn.SetDeadline(time.Time{})
+
+	var b bytes.Buffer
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + acceptKey(req.Header.Get("Sec-WebSocket-Key")) + "\r\n")
+
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+
+	if compression {
+		b.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+
+	_ = res.Header().WriteSubset(&b, handshakeHeaders)
+	b.WriteString("\r\n")
+
+	if _, err := brw.Writer.Write(b.Bytes()); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	if err := brw.Writer.Flush(); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	conn := newConn(netConn, brw.Reader, brw.Writer, false, o.readLimit)
+	conn.subprotocol = subprotocol
+	conn.compression = compression
+
+	return conn, nil
+}
+
+// handshakeHeaders are the response headers that are written by Upgrade or
+// don't apply to a switched protocol, so they aren't copied from the response.
+var handshakeHeaders = map[string]bool{
+	"Connection":               true,
+	"Content-Length":           true,
+	"Content-Type":             true,
+	"Sec-Websocket-Accept":     true,
+	"Sec-Websocket-Extensions": true,
+	"Sec-Websocket-Protocol":   true,
+	"Transfer-Encoding":        true,
+	"Upgrade":                  true,
+}
+
+// checkHandshake validates the handshake request, returning the status it
+// should be rejected with if it's invalid.
+func checkHandshake(req *http.Request, o options) (int, error) {
+	if req.Method != http.MethodGet {
+		return http.StatusMethodNotAllowed, fmt.Errorf("%w: method must be GET", ErrBadHandshake)
+	}
+
+	if !headerContainsToken(req.Header, "Connection", "upgrade") {
+		return http.StatusBadRequest, fmt.Errorf("%w: Connection header must contain upgrade", ErrBadHandshake)
+	}
+
+	if !headerContainsToken(req.Header, "Upgrade", "websocket") {
+		return http.StatusBadRequest, fmt.Errorf("%w: Upgrade header must contain websocket", ErrBadHandshake)
+	}
+
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		return http.StatusUpgradeRequired, fmt.Errorf("%w: unsupported Sec-WebSocket-Version", ErrBadHandshake)
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(req.Header.Get("Sec-WebSocket-Key")); err != nil || len(key) != 16 {
+		return http.StatusBadRequest, fmt.Errorf("%w: invalid Sec-WebSocket-Key", ErrBadHandshake)
+	}
+
+	if !o.checkOrigin(req) {
+		return http.StatusForbidden, fmt.Errorf("%w: origin not allowed", ErrBadHandshake)
+	}
+
+	return 0, nil
+}
+
+// sameOrigin reports whether the request has no Origin header, or an Origin
+// whose host matches the Host header.
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, req.Host)
+}
+
+// acceptKey computes the Sec-WebSocket-Accept header for key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// selectSubprotocol returns the first of the supported subprotocols that the
+// client requested.
+func selectSubprotocol(req *http.Request, supported []string) string {
+	for _, protocol := range supported {
+		if headerContainsToken(req.Header, "Sec-WebSocket-Protocol", protocol) {
+			return protocol
+		}
+	}
+
+	return ""
+}
+
+// headerContainsToken reports whether the comma separated values of the header
+// contain token, ignoring case.
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
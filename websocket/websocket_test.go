@@ -0,0 +1,294 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/fernet"
+	"github.com/blakewilliams/fernet/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer starts a server that upgrades requests to `/ws` and passes the
+// connection to handle, closing it once handle returns.
+func newServer(t *testing.T, handle func(ctx context.Context, conn *Conn), opts ...Option) *httptest.Server {
+	t.Helper()
+
+	router := fernet.New(func(r fernet.RequestContext) fernet.RequestContext { return r })
+	router.Use(middleware.RequestID[fernet.RequestContext]())
+	router.Get("/ws", func(ctx context.Context, r fernet.RequestContext) {
+		conn, err := Upgrade(r, opts...)
+		if err != nil {
+			return
+		}
+		defer conn.Close(StatusNormalClosure, "")
+
+		handle(ctx, conn)
+	})
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// dial performs the client side of the handshake with the server and returns
+// a client connection.
+func dial(t *testing.T, server *httptest.Server, header http.Header) (*Conn, *http.Response) {
+	t.Helper()
+
+	netConn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = netConn.Close() })
+
+	key := make([]byte, 16)
+	_, err = rand.Read(key)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+	require.NoError(t, err)
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, req.Write(netConn))
+
+	br := bufio.NewReader(netConn)
+	res, err := http.ReadResponse(br, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	require.Equal(t, acceptKey(req.Header.Get("Sec-WebSocket-Key")), res.Header.Get("Sec-WebSocket-Accept"))
+
+	conn := newConn(netConn, br, bufio.NewWriter(netConn), true, 32<<20)
+	conn.subprotocol = res.Header.Get("Sec-WebSocket-Protocol")
+	conn.compression = strings.HasPrefix(res.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	return conn, res
+}
+
+// echo writes every message read from conn back to it.
+func echo(ctx context.Context, conn *Conn) {
+	for {
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if err := conn.WriteMessage(typ, data); err != nil {
+			return
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	server := newServer(t, echo, WithSubprotocols("v2.chat", "chat"))
+
+	client, res := dial(t, server, http.Header{"Sec-Websocket-Protocol": {"chat, v2.chat"}})
+	defer client.Close(StatusNormalClosure, "")
+
+	require.Equal(t, "websocket", res.Header.Get("Upgrade"))
+	require.Equal(t, "v2.chat", res.Header.Get("Sec-WebSocket-Protocol"))
+	require.Empty(t, res.Header.Get("Sec-WebSocket-Extensions"))
+	// Headers set by middleware are included in the handshake response.
+	require.NotEmpty(t, res.Header.Get("X-Request-ID"))
+
+	require.NoError(t, client.WriteMessage(TextMessage, []byte("hello")))
+	typ, data, err := client.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, TextMessage, typ)
+	require.Equal(t, "hello", string(data))
+
+	payload := make([]byte, 70000)
+	_, _ = rand.Read(payload)
+	require.NoError(t, client.WriteMessage(BinaryMessage, payload))
+	typ, data, err = client.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, BinaryMessage, typ)
+	require.Equal(t, payload, data)
+
+	require.NoError(t, client.WriteMessage(TextMessage, nil))
+	_, data, err = client.ReadMessage()
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
+
+func TestUpgrade_Compression(t *testing.T) {
+	server := newServer(t, echo, WithCompression())
+
+	client, res := dial(t, server, http.Header{"Sec-Websocket-Extensions": {"permessage-deflate; client_max_window_bits"}})
+	defer client.Close(StatusNormalClosure, "")
+
+	require.Equal(t, "permessage-deflate; server_no_context_takeover; client_no_context_takeover", res.Header.Get("Sec-WebSocket-Extensions"))
+
+	message := strings.Repeat("compressible ", 1000)
+	require.NoError(t, client.WriteMessage(TextMessage, []byte(message)))
+
+	// The echoed message is sent as a single compressed frame.
+	h, err := readFrameHeader(client.br)
+	require.NoError(t, err)
+	require.True(t, h.fin)
+	require.True(t, h.rsv1)
+	require.Less(t, h.length, int64(len(message)))
+
+	payload, err := client.readPayload(nil, h)
+	require.NoError(t, err)
+	data, err := decompress(payload, int64(len(message)))
+	require.NoError(t, err)
+	require.Equal(t, message, string(data))
+
+	w, err := client.NextWriter(TextMessage)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("fragmented "))
+	_, _ = w.Write([]byte("and compressed"))
+	require.NoError(t, w.Close())
+
+	_, data, err = client.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "fragmented and compressed", string(data))
+
+	require.NoError(t, client.WriteMessage(BinaryMessage, nil))
+	_, data, err = client.ReadMessage()
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
+
+func TestUpgrade_CompressionDeclined(t *testing.T) {
+	testCases := map[string]struct {
+		opts       []Option
+		extensions string
+	}{
+		"not enabled":         {extensions: "permessage-deflate"},
+		"not offered":         {opts: []Option{WithCompression()}, extensions: "x-webkit-deflate-frame"},
+		"smaller window":      {opts: []Option{WithCompression()}, extensions: "permessage-deflate; server_max_window_bits=10"},
+		"unknown parameter":   {opts: []Option{WithCompression()}, extensions: "permessage-deflate; unknown"},
+		"no extension header": {opts: []Option{WithCompression()}},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			server := newServer(t, echo, tc.opts...)
+
+			header := http.Header{}
+			if tc.extensions != "" {
+				header.Set("Sec-WebSocket-Extensions", tc.extensions)
+			}
+
+			client, res := dial(t, server, header)
+			defer client.Close(StatusNormalClosure, "")
+
+			require.Empty(t, res.Header.Get("Sec-WebSocket-Extensions"))
+
+			require.NoError(t, client.WriteMessage(TextMessage, []byte("hello")))
+			_, data, err := client.ReadMessage()
+			require.NoError(t, err)
+			require.Equal(t, "hello", string(data))
+		})
+	}
+}
+
+func TestUpgrade_BadHandshake(t *testing.T) {
+	valid := func(req *http.Request) {
+		req.Header.Set("Connection", "keep-alive, Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	}
+
+	testCases := map[string]struct {
+		method   string
+		opts     []Option
+		prepare  func(req *http.Request)
+		status   int
+		upgraded bool
+	}{
+		"wrong method": {
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+		},
+		"missing connection header": {
+			prepare: func(req *http.Request) { req.Header.Del("Connection") },
+			status:  http.StatusBadRequest,
+		},
+		"missing upgrade header": {
+			prepare: func(req *http.Request) { req.Header.Set("Upgrade", "h2c") },
+			status:  http.StatusBadRequest,
+		},
+		"unsupported version": {
+			prepare: func(req *http.Request) { req.Header.Set("Sec-WebSocket-Version", "8") },
+			status:  http.StatusUpgradeRequired,
+		},
+		"invalid key": {
+			prepare: func(req *http.Request) { req.Header.Set("Sec-WebSocket-Key", "short") },
+			status:  http.StatusBadRequest,
+		},
+		"cross origin": {
+			prepare: func(req *http.Request) { req.Header.Set("Origin", "https://evil.example") },
+			status:  http.StatusForbidden,
+		},
+		"allowed origin": {
+			opts:     []Option{WithCheckOrigin(func(req *http.Request) bool { return true })},
+			prepare:  func(req *http.Request) { req.Header.Set("Origin", "https://app.example") },
+			status:   http.StatusInternalServerError,
+			upgraded: true,
+		},
+		"same origin": {
+			prepare:  func(req *http.Request) { req.Header.Set("Origin", "http://example.com") },
+			status:   http.StatusInternalServerError,
+			upgraded: true,
+		},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			var upgradeErr error
+
+			router := fernet.New(func(r fernet.RequestContext) fernet.RequestContext { return r })
+			upgrade := func(ctx context.Context, r fernet.RequestContext) {
+				_, upgradeErr = Upgrade(r, tc.opts...)
+			}
+			router.Get("/ws", upgrade)
+			router.Post("/ws", upgrade)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			req := httptest.NewRequest(method, "/ws", nil)
+			valid(req)
+			if tc.prepare != nil {
+				tc.prepare(req)
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Error(t, upgradeErr)
+			require.Equal(t, tc.status, res.Code)
+
+			if tc.upgraded {
+				// The handshake is valid, but the recorder can't be hijacked.
+				require.NotErrorIs(t, upgradeErr, ErrBadHandshake)
+				return
+			}
+
+			require.ErrorIs(t, upgradeErr, ErrBadHandshake)
+
+			if tc.status == http.StatusUpgradeRequired {
+				require.Equal(t, "13", res.Header().Get("Sec-WebSocket-Version"))
+			}
+		})
+	}
+}